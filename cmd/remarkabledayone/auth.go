@@ -0,0 +1,108 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+)
+
+// runAuth implements the "auth" command.
+func runAuth(_ context.Context, log *slog.Logger, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a subcommand: login, status")
+	}
+
+	auth, err := rm.NewAuth(cfg.TokenPath, cfg.DeviceToken)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "login":
+		return runAuthLogin(log, auth, args[1:])
+	case "status":
+		return runAuthStatus(auth)
+	default:
+		return fmt.Errorf("unknown auth subcommand %q, expected: login, status", args[0])
+	}
+}
+
+// runAuthLogin implements the "auth login" command.
+func runAuthLogin(log *slog.Logger, auth *rm.Auth, args []string) error {
+	fs := flag.NewFlagSet("auth login", flag.ContinueOnError)
+	code := fs.String("code", "", fmt.Sprintf("One-time code from %s", rm.ConnectURL))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *code == "" {
+		return fmt.Errorf("--code is required, get one from %s", rm.ConnectURL)
+	}
+
+	if err := auth.Login(*code); err != nil {
+		return err
+	}
+
+	log.Info("logged in", "path", auth.Path)
+	return nil
+}
+
+// runAuthStatus implements the "auth status" command.
+func runAuthStatus(auth *rm.Auth) error {
+	st, err := auth.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Token file: %s\n", st.Path)
+	if st.DeviceTokenSource == "" {
+		fmt.Fprintf(os.Stdout, "Device token: missing, run \"remarkabledayone auth login --code <code>\"\n")
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "Device token: present (from %s), %s\n", st.DeviceTokenSource, formatExpiry(st.DeviceTokenExpires))
+
+	if st.UserTokenExpires.IsZero() {
+		fmt.Fprintf(os.Stdout, "User token: missing, one will be created on the next sync\n")
+		return nil
+	}
+	if st.User != "" {
+		fmt.Fprintf(os.Stdout, "User: %s\n", st.User)
+	}
+	fmt.Fprintf(os.Stdout, "User token: %s (refreshed on every sync)\n", formatExpiry(st.UserTokenExpires))
+
+	return nil
+}
+
+// formatExpiry formats when a token expires.
+func formatExpiry(t time.Time) string {
+	switch {
+	case t.IsZero():
+		return "does not expire"
+	case time.Now().After(t):
+		return "expired at " + t.Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("expires at %s (in %s)", t.Format(time.RFC3339), time.Until(t).Round(time.Minute))
+	}
+}
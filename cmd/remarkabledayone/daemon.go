@@ -0,0 +1,120 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/api"
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/metrics"
+	"github.com/jaredallard/remarkabledayone/internal/notify"
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// runDaemon implements the "daemon" command. It syncs on an interval
+// until interrupted, optionally serving metrics and the API.
+func runDaemon(ctx context.Context, log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	interval := fs.Duration("interval", cfg.SyncInterval, "Time between syncs")
+	metricsAddr := fs.String("metrics-addr", cfg.MetricsAddr, `Address to serve Prometheus metrics on, e.g., ":9090"`)
+	fs.StringVar(&cfg.APIAddr, "api-addr", cfg.APIAddr, `Address to serve the API on, e.g., "127.0.0.1:8080"`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return &exitError{exitUsage, fmt.Errorf("--interval must be positive")}
+	}
+
+	n, err := notify.New(log.With("component", "notify"), cfg)
+	if err != nil {
+		return err
+	}
+
+	s, err := syncer.New(log, cfg)
+	if err != nil {
+		err = fmt.Errorf("failed to create syncer: %w", err)
+		res := syncer.NewResult(cfg.DocumentName)
+		res.Error = err.Error()
+		sendNotification(ctx, log, n, res)
+		return err
+	}
+	defer s.Close() //nolint:errcheck // Why: Best effort.
+
+	runner := syncer.NewRunner(s)
+	runner.OnRun = func(ctx context.Context, run *syncer.Run) {
+		sendNotification(ctx, log, n, run.Result)
+	}
+	// Syncs started through the API may still be running when the daemon
+	// stops, the state is only closed once they're done.
+	defer runner.Wait()
+
+	errc := make(chan error, 2)
+	if *metricsAddr != "" {
+		go func() {
+			if err := metrics.Serve(ctx, log.With("component", "metrics"), *metricsAddr); err != nil {
+				errc <- fmt.Errorf("failed to serve metrics: %w", err)
+			}
+		}()
+	}
+	if cfg.APIAddr != "" {
+		srv := api.New(ctx, log.With("component", "api"), runner, cfg.APIToken)
+		go func() {
+			if err := srv.Serve(ctx, cfg.APIAddr); err != nil {
+				errc <- fmt.Errorf("failed to serve API: %w", err)
+			}
+		}()
+	}
+
+	log.Info("starting daemon", "interval", *interval)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		// Failures are reported through the logs and metrics, the next run
+		// tries again. Syncs triggered through the API in the meantime
+		// don't delay this one.
+		done, err := runner.Start(ctx, nil)
+		if errors.Is(err, syncer.ErrRunning) {
+			log.Info("sync already running, skipping")
+		} else if err != nil {
+			return err
+		} else {
+			run := <-done
+			if run.Err != nil && ctx.Err() == nil {
+				log.With("error", run.Err).Error("sync failed")
+			} else if run.Err == nil {
+				log.Info("sync finished", "created", len(run.Result.Created), "failed", len(run.Result.Failed),
+					"duration", run.Result.Duration)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("stopping daemon")
+			return nil
+		case err := <-errc:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
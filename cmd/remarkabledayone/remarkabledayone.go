@@ -20,39 +20,201 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	charmlog "github.com/charmbracelet/log"
 	"github.com/jaredallard/remarkabledayone/internal/config"
-	"github.com/jaredallard/remarkabledayone/internal/syncer"
+	"github.com/jaredallard/remarkabledayone/internal/rm"
 )
 
+// Contains the exit codes of the CLI.
+const (
+	// exitFailure is used when a command fails.
+	exitFailure = 1
+
+	// exitUsage is used when the CLI is used incorrectly.
+	exitUsage = 2
+
+	// exitPartial is used when some of the pages of a sync failed.
+	exitPartial = 3
+
+	// exitAuth is used when authenticating with the reMarkable cloud
+	// failed.
+	exitAuth = 4
+
+	// exitTotal is used when every attempted page of a sync failed.
+	exitTotal = 5
+)
+
+// exitError is an error that exits the CLI with a specific code.
+type exitError struct {
+	code int
+	err  error
+}
+
+// Error implements [error].
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code for the provided error.
+func exitCode(err error) int {
+	var ee *exitError
+	switch {
+	case errors.As(err, &ee):
+		return ee.code
+	case errors.Is(err, rm.ErrNotAuthenticated):
+		return exitAuth
+	default:
+		return exitFailure
+	}
+}
+
+// command is a subcommand of the CLI.
+type command struct {
+	// name is the name of the command.
+	name string
+
+	// description is a short description shown in the usage.
+	description string
+
+	// run runs the command with the remaining arguments.
+	run func(ctx context.Context, log *slog.Logger, cfg *config.Config, args []string) error
+}
+
+// commands is the list of supported commands. The first one is used
+// when no command is provided.
+var commands = []command{
+	{"sync", "Sync new pages to Day One (default)", runSync},
+	{"daemon", "Sync on an interval, optionally serving metrics", runDaemon},
+	{"auth", "Manage authentication with the reMarkable cloud", runAuth},
+	{"status", "Show synced and failing pages", runStatus},
+	{"retry", "Retry failing or quarantined pages on the next sync", runRetry},
+	{"state", "Forget, mark, export, import or diff synced pages", runState},
+}
+
+// usage prints the usage of the CLI.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: remarkabledayone [--profile name] [--state path] [command] [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nGlobal flags:\n"+
+		"  --profile  Use a profile with its own config, state, cache and tokens\n"+
+		"  --state    Path of the state file, instead of searching for it\n")
+	fmt.Fprintf(os.Stderr, "\nRun \"remarkabledayone <command> --help\" for the flags of a command.\n")
+}
+
+// globalFlags are the flags accepted before the command.
+type globalFlags struct {
+	// profile is the name of the profile to use, see [config.Load].
+	profile string
+
+	// state is the path of the state, see [config.Config.StatePath].
+	state string
+}
+
+// parseGlobalFlags parses the global flags at the start of args,
+// returning the remaining arguments. Parsing stops at the first argument
+// that isn't a global flag, so that flags of the default command can be
+// passed without naming it.
+func parseGlobalFlags(args []string) (*globalFlags, []string, error) {
+	g := &globalFlags{}
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+
+		var dest *string
+		switch name {
+		case "profile":
+			dest = &g.profile
+		case "state":
+			dest = &g.state
+		default:
+			return g, args, nil
+		}
+
+		if !hasValue {
+			if len(args) < 2 {
+				return nil, nil, fmt.Errorf("flag needs an argument: --%s", name)
+			}
+			value, args = args[1], args[1:]
+		}
+		*dest = value
+		args = args[1:]
+	}
+	return g, args, nil
+}
+
 // main is the entrypoint for the remarkabledayone utility.
 func main() {
 	handler := charmlog.New(os.Stderr)
 	log := slog.New(handler)
 
-	cfg, err := config.Load(log.With("component", "config"))
+	global, args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	// Find the command to run, defaulting to the first one.
+	cmd := commands[0]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		found := false
+		for _, c := range commands {
+			if c.name == args[0] {
+				cmd, args, found = c, args[1:], true
+				break
+			}
+		}
+		if !found {
+			usage()
+			os.Exit(exitUsage)
+		}
+	}
+
+	cfg, err := config.Load(log.With("component", "config"), global.profile)
 	if err != nil {
 		log.With("error", err).Error("failed to load config")
 		os.Exit(1)
 	}
+	if global.state != "" {
+		cfg.StatePath = global.state
+	}
 
 	if os.Getenv("ENV") == "development" {
 		handler.SetLevel(charmlog.DebugLevel)
 		log.Debug("debug logging enabled")
 	}
 
-	//nolint:gocritic // Why: Acceptable shadow.
-	syncer, err := syncer.New(log, cfg)
-	if err != nil {
-		log.With("error", err).Error("failed to create syncer")
-		os.Exit(1)
-	}
+	// Cancel the command on the first interrupt, then restore the default
+	// behaviour so that a second one exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	if err := syncer.Sync(); err != nil {
-		log.With("error", err).Error("failed to sync")
-		os.Exit(1)
+	err = cmd.run(ctx, log, cfg, args)
+	stop()
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.With("error", err).Error("command failed", "command", cmd.name)
+		os.Exit(exitCode(err))
 	}
 }
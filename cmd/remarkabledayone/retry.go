@@ -0,0 +1,82 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/state"
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// runRetry implements the "retry" command. It clears the failures of
+// the provided pages, or of every page if none are provided, so that
+// they're attempted on the next sync.
+func runRetry(_ context.Context, log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("retry", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remarkabledayone retry [page IDs...]\n\n"+
+			"Clears the failures of the provided pages, or of every failing page.\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	st, err := syncer.OpenState(log, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer st.Close() //nolint:errcheck // Why: Best effort.
+
+	pages, err := st.Pages()
+	if err != nil {
+		return err
+	}
+
+	ids := fs.Args()
+	if len(ids) == 0 {
+		for id, p := range pages {
+			if p.Failure != nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	cleared := 0
+	for _, id := range ids {
+		if p, ok := pages[id]; !ok || p.Failure == nil {
+			log.With("page", id).Warn("page has no failures")
+			continue
+		}
+		if err := st.UpdatePage(id, nil, func(p *state.Page) { p.Failure = nil }); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		cleared++
+	}
+	if cleared == 0 {
+		log.Info("no pages to retry")
+		return nil
+	}
+
+	log.Info("pages will be retried on the next sync", "pages", cleared)
+	return nil
+}
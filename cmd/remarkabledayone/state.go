@@ -0,0 +1,400 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/state"
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// stateSubcommands is the list of subcommands of the "state" command.
+const stateSubcommands = "forget, mark-synced, export, import, diff"
+
+// runState implements the "state" command.
+func runState(ctx context.Context, log *slog.Logger, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return &exitError{exitUsage, fmt.Errorf("expected a subcommand: %s", stateSubcommands)}
+	}
+
+	switch args[0] {
+	case "forget":
+		return runStateForget(log, cfg, args[1:])
+	case "mark-synced":
+		return runStateMarkSynced(log, cfg, args[1:])
+	case "export":
+		return runStateExport(log, cfg, args[1:])
+	case "import":
+		return runStateImport(log, cfg, args[1:])
+	case "diff":
+		return runStateDiff(ctx, log, cfg, args[1:])
+	default:
+		return &exitError{exitUsage, fmt.Errorf("unknown state subcommand %q, expected: %s", args[0], stateSubcommands)}
+	}
+}
+
+// findDocument returns the ID of the synced document with the provided
+// ID, name or path.
+func findDocument(docs map[string]*state.Document, name string) (string, error) {
+	if _, ok := docs[name]; ok {
+		return name, nil
+	}
+
+	var found []string
+	for id, d := range docs {
+		if d.Path == name || d.Name == name {
+			found = append(found, id)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no synced document %q", name)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("multiple synced documents named %q, use the path or ID", name)
+	}
+}
+
+// runStateForget implements the "state forget" command.
+func runStateForget(log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("state forget", flag.ContinueOnError)
+	document := fs.String("document", "", "Forget this document and all of its pages (name, path or ID)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remarkabledayone state forget [--document name] [page IDs...]\n\n"+
+			"Removes pages from the state, so that they're synced again.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *document == "" && fs.NArg() == 0 {
+		return &exitError{exitUsage, fmt.Errorf("expected page IDs or --document")}
+	}
+
+	st, err := syncer.OpenState(log, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer st.Close() //nolint:errcheck // Why: Best effort.
+
+	pages, err := st.Pages()
+	if err != nil {
+		return err
+	}
+
+	ids := fs.Args()
+	if *document != "" {
+		docs, err := st.Documents()
+		if err != nil {
+			return err
+		}
+		docID, err := findDocument(docs, *document)
+		if err != nil {
+			return err
+		}
+
+		// Pages that were deleted from the document aren't in its list.
+		ids = append(ids, docs[docID].Pages...)
+		for id, p := range pages {
+			if p.Document == docID {
+				ids = append(ids, id)
+			}
+		}
+		if err := st.DeleteDocuments(docID); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		log.Info("forgot document", "path", docs[docID].Path)
+	}
+
+	forget := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		if _, ok := pages[id]; !ok {
+			if *document == "" {
+				log.With("page", id).Warn("page is unknown")
+			}
+			continue
+		}
+		forget = append(forget, id)
+	}
+	if err := st.DeletePages(forget...); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	log.Info("pages will be synced again on the next sync", "pages", len(forget))
+	return nil
+}
+
+// runStateMarkSynced implements the "state mark-synced" command.
+func runStateMarkSynced(log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("state mark-synced", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remarkabledayone state mark-synced <page IDs...>\n\n"+
+			"Records pages as synced without creating entries. Only pages in the\n"+
+			"state or in a synced document can be marked. To select pages by\n"+
+			"number or date instead, use \"sync --mark-synced\".\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return &exitError{exitUsage, fmt.Errorf("expected page IDs")}
+	}
+
+	st, err := syncer.OpenState(log, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer st.Close() //nolint:errcheck // Why: Best effort.
+
+	pages, err := st.Pages()
+	if err != nil {
+		return err
+	}
+	docs, err := st.Documents()
+	if err != nil {
+		return err
+	}
+
+	// Pages that haven't been synced yet are only known through the
+	// document they're in.
+	known := make(map[string]struct{}, len(pages))
+	for id := range pages {
+		known[id] = struct{}{}
+	}
+	for _, d := range docs {
+		for _, id := range d.Pages {
+			known[id] = struct{}{}
+		}
+	}
+
+	now := time.Now()
+	marked := 0
+	for _, id := range fs.Args() {
+		if _, ok := known[id]; !ok {
+			log.With("page", id).Warn("page is unknown")
+			continue
+		}
+
+		err := st.UpdatePage(id, nil, func(p *state.Page) {
+			p.Synced = true
+			p.SyncedAt = now
+			p.Failure = nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		marked++
+	}
+
+	log.Info("marked pages as synced", "pages", marked)
+	return nil
+}
+
+// runStateExport implements the "state export" command.
+func runStateExport(log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("state export", flag.ContinueOnError)
+	output := fs.String("output", "-", `File to write the state to, "-" for stdout`)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remarkabledayone state export [--output file]\n\n"+
+			"Writes the state as JSON, e.g., as a backup or to import it on\n"+
+			"another machine.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := syncer.OpenState(log, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer store.Close() //nolint:errcheck // Why: Best effort.
+
+	st, err := store.Export()
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		return writeJSON(os.Stdout, st)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(f, st); err != nil {
+		f.Close() //nolint:errcheck // Why: Best effort.
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	log.Info("exported state", "path", *output, "pages", len(st.Pages), "documents", len(st.Documents))
+	return nil
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// runStateImport implements the "state import" command.
+func runStateImport(log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("state import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remarkabledayone state import <file>\n\n"+
+			"Adds the pages and documents of a state exported with \"state export\",\n"+
+			"replacing the ones that are already known. \"-\" reads from stdin.\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return &exitError{exitUsage, fmt.Errorf("expected a file to import")}
+	}
+
+	r := io.Reader(os.Stdin)
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path) //#nosec:G304 // Why: Safe for our usecase.
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck // Why: Best effort.
+		r = f
+	}
+
+	imported := &state.State{}
+	if err := json.NewDecoder(r).Decode(imported); err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+	if imported.Version != state.CurrentVersion {
+		return fmt.Errorf("can't import state version %d, expected version %d; export it again with the same version of remarkabledayone",
+			imported.Version, state.CurrentVersion)
+	}
+	for id, p := range imported.Pages {
+		if p == nil {
+			delete(imported.Pages, id)
+		}
+	}
+	for id, d := range imported.Documents {
+		if d == nil {
+			delete(imported.Documents, id)
+		}
+	}
+
+	st, err := syncer.OpenState(log, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer st.Close() //nolint:errcheck // Why: Best effort.
+
+	if err := st.Import(imported); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	log.Info("imported state", "pages", len(imported.Pages), "documents", len(imported.Documents))
+	return nil
+}
+
+// runStateDiff implements the "state diff" command.
+func runStateDiff(ctx context.Context, log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("state diff", flag.ContinueOnError)
+	document := fs.String("document", "", "Name or path of the document to compare instead of DOCUMENT_NAME")
+	output := fs.String("output", "text", `Output format, "text" or "json"`)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: remarkabledayone state diff [--document name]\n\n"+
+			"Compares the state with the document as it currently is, showing what\n"+
+			"the next sync would change.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *output != "text" && *output != "json" {
+		return &exitError{exitUsage, fmt.Errorf("invalid --output %q, expected \"text\" or \"json\"", *output)}
+	}
+
+	if *document == "" && cfg.DocumentName == "" {
+		return &exitError{exitUsage, fmt.Errorf("DOCUMENT_NAME or --document must be set")}
+	}
+
+	// Only reads the state, so that it works while the daemon is running.
+	s, err := syncer.NewReadOnly(log, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create syncer: %w", err)
+	}
+	defer s.Close() //nolint:errcheck // Why: Best effort.
+
+	d, err := s.Diff(ctx, *document)
+	if err != nil {
+		return err
+	}
+
+	if *output == "json" {
+		return writeJSON(os.Stdout, d)
+	}
+
+	fmt.Fprintf(os.Stdout, "Document: %s\n", d.Document)
+	fmt.Fprintf(os.Stdout, "Synced pages: %d\n", len(d.Synced))
+	for _, l := range []struct {
+		title string
+		pages []syncer.PageResult
+	}{
+		{"Pending pages (synced on the next sync)", d.Pending},
+		{"Moved pages (from another document)", d.Moved},
+		{"Restored pages (previously deleted)", d.Restored},
+	} {
+		if len(l.pages) == 0 {
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "\n%s: %d\n", l.title, len(l.pages))
+		for _, p := range l.pages {
+			if p.Reason != "" {
+				fmt.Fprintf(os.Stdout, "  Page %d (%s): %s\n", p.Page, p.ID, p.Reason)
+			} else {
+				fmt.Fprintf(os.Stdout, "  Page %d (%s)\n", p.Page, p.ID)
+			}
+		}
+	}
+	if len(d.Removed) > 0 {
+		fmt.Fprintf(os.Stdout, "\nRemoved pages (marked as deleted on the next sync): %d\n", len(d.Removed))
+		for _, id := range d.Removed {
+			fmt.Fprintf(os.Stdout, "  %s\n", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+	"github.com/jaredallard/remarkabledayone/internal/state"
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// runStatus implements the "status" command.
+func runStatus(_ context.Context, log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	log = log.With("component", "state")
+	statePath, err := syncer.StatePath(log, cfg)
+	if err != nil {
+		return err
+	}
+	if err := printFiles(cfg, statePath); err != nil {
+		return err
+	}
+
+	store, err := state.Open(log, cfg.StateBackend, statePath, true)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stdout, "State: none yet, nothing has been synced\n")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer store.Close() //nolint:errcheck // Why: Best effort.
+
+	st, err := store.Export()
+	if err != nil {
+		return err
+	}
+	synced, deleted := 0, 0
+	for _, p := range st.Pages {
+		if p.Synced {
+			synced++
+		}
+		if !p.DeletedAt.IsZero() {
+			deleted++
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Synced pages: %d\n", synced)
+	if deleted > 0 {
+		fmt.Fprintf(os.Stdout, "Deleted pages: %d\n", deleted)
+	}
+
+	docIDs := make([]string, 0, len(st.Documents))
+	for id := range st.Documents {
+		docIDs = append(docIDs, id)
+	}
+	sort.Slice(docIDs, func(i, j int) bool { return st.Documents[docIDs[i]].Path < st.Documents[docIDs[j]].Path })
+	for _, id := range docIDs {
+		d := st.Documents[id]
+		fmt.Fprintf(os.Stdout, "Document %q: %d pages, %d pending (last synced %s)\n",
+			d.Path, len(d.Pages), d.Pending, d.LastSync.Format(time.RFC3339))
+	}
+
+	failures := make(map[string]*state.PageFailure)
+	for id, p := range st.Pages {
+		if p.Failure != nil {
+			failures[id] = p.Failure
+		}
+	}
+	if len(failures) == 0 {
+		fmt.Fprintf(os.Stdout, "Failing pages: none\n")
+		return nil
+	}
+
+	ids := make([]string, 0, len(failures))
+	for id := range failures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	fmt.Fprintf(os.Stdout, "Failing pages: %d\n", len(ids))
+	for _, id := range ids {
+		f := failures[id]
+
+		next := "retrying on the next sync"
+		switch {
+		case f.Quarantined(cfg.MaxPageFailures):
+			next = "quarantined, run \"remarkabledayone retry\" to try again"
+		case time.Now().Before(f.NextAttempt):
+			next = "retrying after " + f.NextAttempt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(os.Stdout, "\n  Page: %s\n  Failures: %d (last at %s)\n  Status: %s\n  Error: %s\n",
+			id, f.Count, f.LastAttempt.Format(time.RFC3339), next, f.LastError)
+	}
+
+	return nil
+}
+
+// printFiles prints the profile and the files used by it.
+func printFiles(cfg *config.Config, statePath string) error {
+	cacheDir, err := syncer.CacheDir(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Profile: %s\n", cmp.Or(cfg.Profile, "default"))
+	fmt.Fprintf(os.Stdout, "Config file: %s\n", cmp.Or(cfg.EnvFile, "none"))
+	fmt.Fprintf(os.Stdout, "State file: %s\n", statePath)
+	if cfg.Source == "local" {
+		fmt.Fprintf(os.Stdout, "Documents: %s\n", cfg.LocalDir)
+	} else {
+		auth, err := rm.NewAuth(cfg.TokenPath, cfg.DeviceToken)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "Token file: %s\n", auth.Path)
+	}
+	fmt.Fprintf(os.Stdout, "Cache directory: %s\n", cacheDir)
+	return nil
+}
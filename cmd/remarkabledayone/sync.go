@@ -0,0 +1,162 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/notify"
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// syncFlags are the flags used to control a sync.
+type syncFlags struct {
+	pages      string
+	since      string
+	until      string
+	last       int
+	markSynced bool
+	noCache    bool
+	output     string
+	document   string
+}
+
+// register registers the flags on the provided flag set.
+func (f *syncFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.pages, "pages", "", `Only sync these pages, e.g., "1-10,15,120-"`)
+	fs.StringVar(&f.since, "since", "", "Only sync pages modified on or after this date (YYYY-MM-DD)")
+	fs.StringVar(&f.until, "until", "", "Only sync pages modified before this date (YYYY-MM-DD)")
+	fs.IntVar(&f.last, "last", 0, "Only sync the last N pages of the document")
+	fs.BoolVar(&f.markSynced, "mark-synced", false, "Mark the selected pages as synced without creating entries")
+	fs.BoolVar(&f.noCache, "no-cache", false, "Download the document even if it's cached")
+	fs.StringVar(&f.document, "document", "", "Name or path of the document to sync instead of DOCUMENT_NAME")
+	fs.StringVar(&f.output, "output", "text", `Output format of the summary, "text" or "json"`)
+}
+
+// options converts the flags into [syncer.Options].
+func (f *syncFlags) options() (*syncer.Options, error) {
+	filter := &syncer.Filter{Last: f.last}
+
+	if f.pages != "" {
+		ranges, err := syncer.ParsePageRanges(f.pages)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --pages: %w", err)
+		}
+		filter.Pages = ranges
+	}
+
+	for _, d := range []struct {
+		name string
+		val  string
+		dest *time.Time
+	}{{"since", f.since, &filter.Since}, {"until", f.until, &filter.Until}} {
+		if d.val == "" {
+			continue
+		}
+		t, err := time.ParseInLocation(time.DateOnly, d.val, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", d.name, err)
+		}
+		*d.dest = t
+	}
+
+	return &syncer.Options{Filter: filter, MarkSynced: f.markSynced, NoCache: f.noCache,
+		Document: f.document}, nil
+}
+
+// writeSummary writes the result of a sync to stdout as JSON.
+func writeSummary(log *slog.Logger, res *syncer.Result) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		log.With("error", err).Error("failed to write summary")
+	}
+}
+
+// sendNotification sends a notification about the result of a sync.
+// Interrupted syncs aren't notified about, and failing to send one only
+// logs an error.
+func sendNotification(ctx context.Context, log *slog.Logger, n *notify.Notifier, res *syncer.Result) {
+	if ctx.Err() != nil {
+		return
+	}
+	if err := n.Notify(ctx, res); err != nil {
+		log.With("error", err).Error("failed to send notification")
+	}
+}
+
+// runSync implements the "sync" command.
+func runSync(ctx context.Context, log *slog.Logger, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	var sf syncFlags
+	sf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if sf.output != "text" && sf.output != "json" {
+		return &exitError{exitUsage, fmt.Errorf("invalid --output %q, expected \"text\" or \"json\"", sf.output)}
+	}
+
+	opts, err := sf.options()
+	if err != nil {
+		return &exitError{exitUsage, err}
+	}
+
+	n, err := notify.New(log.With("component", "notify"), cfg)
+	if err != nil {
+		return err
+	}
+
+	s, err := syncer.New(log, cfg)
+	if err != nil {
+		err = fmt.Errorf("failed to create syncer: %w", err)
+		res := syncer.NewResult(cmp.Or(opts.Document, cfg.DocumentName))
+		res.Error = err.Error()
+		if sf.output == "json" {
+			writeSummary(log, res)
+		}
+		sendNotification(ctx, log, n, res)
+		return err
+	}
+	defer s.Close() //nolint:errcheck // Why: Best effort.
+
+	res, err := s.Sync(ctx, opts)
+	if sf.output == "json" {
+		writeSummary(log, res)
+	}
+	sendNotification(ctx, log, n, res)
+
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to sync: %w", err)
+	case res.TotalFailure():
+		return &exitError{exitTotal, fmt.Errorf("all %d attempted pages failed to sync", len(res.Failed))}
+	case res.PartialFailure():
+		return &exitError{exitPartial, fmt.Errorf("%d pages failed to sync", len(res.Failed))}
+	}
+	return nil
+}
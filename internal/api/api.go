@@ -0,0 +1,178 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package api implements a small local HTTP API for triggering syncs and
+// inspecting their status while running as a daemon.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// Server serves the API. Create with [New].
+type Server struct {
+	log    *slog.Logger
+	runner *syncer.Runner
+
+	// token is the bearer token required for every request. Requests
+	// aren't authenticated if empty.
+	token string
+
+	// ctx is used for syncs triggered through the API, so that they're
+	// cancelled with the daemon rather than with the request.
+	ctx context.Context
+}
+
+// New creates a new API server. Syncs triggered through the API run with
+// the provided context.
+func New(ctx context.Context, log *slog.Logger, runner *syncer.Runner, token string) *Server {
+	return &Server{log: log, runner: runner, token: token, ctx: ctx}
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("POST /sync", s.handleSync)
+	mux.HandleFunc("GET /pages/{id}/preview", s.handlePreview)
+	return s.authenticate(mux)
+}
+
+// Serve serves the API at the provided address until the context is
+// cancelled.
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx) //nolint:errcheck // Why: Best effort.
+	}()
+
+	if s.token == "" {
+		s.log.Warn("API_TOKEN isn't set, the API doesn't require authentication")
+	}
+	s.log.Info("serving API", "addr", addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authenticate requires the bearer token, if one is configured.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleStatus implements "GET /status".
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.runner.Status())
+}
+
+// handleSync implements "POST /sync". A different document than the
+// configured one can be synced with the "document" query parameter. The
+// sync runs in the background unless the "wait" query parameter is true,
+// in which case the result is returned once it's done.
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	wait := false
+	if v := r.URL.Query().Get("wait"); v != "" {
+		var err error
+		if wait, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid wait parameter"))
+			return
+		}
+	}
+
+	opts := &syncer.Options{Document: strings.TrimSpace(r.URL.Query().Get("document"))}
+	done, err := s.runner.Start(s.ctx, opts)
+	if errors.Is(err, syncer.ErrRunning) {
+		writeError(w, http.StatusConflict, err)
+		return
+	} else if errors.Is(err, syncer.ErrStopped) {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.log.Info("sync triggered through the API", "document", opts.Document)
+
+	if !wait {
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "started"})
+		return
+	}
+
+	select {
+	case run := <-done:
+		status := http.StatusOK
+		if run.Err != nil {
+			status = http.StatusInternalServerError
+		}
+		writeJSON(w, status, run)
+	case <-r.Context().Done():
+		// The client went away, the sync carries on.
+	}
+}
+
+// handlePreview implements "GET /pages/{id}/preview".
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	path, err := s.runner.Preview(r.PathValue("id"))
+	if errors.Is(err, syncer.ErrNoPreview) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeFile(w, r, path)
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck // Why: Best effort.
+}
+
+// writeError writes err as a JSON error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
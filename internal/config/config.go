@@ -19,9 +19,13 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -29,40 +33,274 @@ import (
 
 // Config is the configuration for the remarkabledayone CLI.
 type Config struct {
-	// DocumentName is the name of the document to sync.
-	DocumentName string `env:"DOCUMENT_NAME,required"`
+	// DocumentName is the name or path, e.g., "Journals/Daily", of the
+	// document to sync. Required for syncing.
+	DocumentName string `env:"DOCUMENT_NAME"`
+
+	// TokenPath is where reMarkable cloud tokens are stored. Defaults to
+	// rmapi's configuration file.
+	TokenPath string `env:"TOKEN_PATH"`
+
+	// DeviceToken is a reMarkable device token to use instead of the one
+	// stored at TokenPath, e.g., when running in a service.
+	DeviceToken string `env:"REMARKABLE_DEVICE_TOKEN"`
+
+	// VisibleLayersOnly skips layers that are hidden on the device when
+	// rendering pages.
+	VisibleLayersOnly bool `env:"VISIBLE_LAYERS_ONLY"`
+
+	// ExcludeLayers is a regular expression, layers with a matching name
+	// are not rendered.
+	ExcludeLayers *regexp.Regexp `env:"EXCLUDE_LAYERS"`
+
+	// SeparateLayers renders each layer of a page into its own image.
+	SeparateLayers bool `env:"SEPARATE_LAYERS"`
+
+	// SkipBlankPages skips pages without any content. They are not
+	// recorded as synced, so they will be synced once written on.
+	SkipBlankPages bool `env:"SKIP_BLANK_PAGES" envDefault:"true"`
+
+	// BlankPageThreshold is the fraction of the page (0-1) that must be
+	// covered by ink for a page to not be considered blank. When zero,
+	// only pages without any strokes are considered blank.
+	BlankPageThreshold float64 `env:"BLANK_PAGE_THRESHOLD"`
+
+	// RenderWorkers is the number of pages rendered at the same time.
+	RenderWorkers int `env:"RENDER_WORKERS" envDefault:"4"`
+
+	// SyncTimeout is the maximum time a sync can take. Zero disables the
+	// timeout.
+	SyncTimeout time.Duration `env:"SYNC_TIMEOUT"`
+
+	// PageTimeout is the maximum time spent rendering and recognizing a
+	// single page. Zero disables the timeout.
+	PageTimeout time.Duration `env:"PAGE_TIMEOUT" envDefault:"5m"`
+
+	// OCR enables handwriting recognition of rendered pages. Recognized
+	// text is added to the body of the entry.
+	OCR bool `env:"OCR"`
+
+	// OCRProvider is the recognition provider to use, either "tesseract"
+	// or "http".
+	OCRProvider string `env:"OCR_PROVIDER" envDefault:"tesseract"`
+
+	// OCRLanguage is the language to recognize, e.g., "eng" or "eng+deu"
+	// for tesseract. Sent as a hint to HTTP providers.
+	OCRLanguage string `env:"OCR_LANGUAGE" envDefault:"eng"`
+
+	// OCRTimeout is the maximum amount of time to spend recognizing a
+	// single page.
+	OCRTimeout time.Duration `env:"OCR_TIMEOUT" envDefault:"1m"`
+
+	// OCRMinConfidence is the minimum confidence (0-100) a recognized word
+	// must have to be included.
+	OCRMinConfidence float64 `env:"OCR_MIN_CONFIDENCE" envDefault:"60"`
+
+	// OCRURL is the endpoint of the recognition server used by the "http"
+	// provider.
+	OCRURL string `env:"OCR_URL"`
+
+	// OCRToken is sent as a bearer token to the recognition server, if
+	// set.
+	OCRToken string `env:"OCR_TOKEN"`
+
+	// OCRInput is what is sent to the recognition server, either "image"
+	// (rendered PNGs) or "strokes" (the raw ".rm" file).
+	OCRInput string `env:"OCR_INPUT" envDefault:"image"`
+
+	// Source is where documents are read from, either "cloud" (the
+	// reMarkable cloud) or "local" (a copy of the device's document tree
+	// in LocalDir).
+	Source string `env:"SOURCE" envDefault:"cloud"`
+
+	// LocalDir is the directory containing a copy of the device's
+	// document tree ("xochitl"), used by the "local" source.
+	LocalDir string `env:"LOCAL_DIR"`
+
+	// MaxPageFailures is the number of consecutive failures after which a
+	// page is quarantined and no longer attempted until it's retried with
+	// the "retry" command. Zero disables quarantining.
+	MaxPageFailures int `env:"MAX_PAGE_FAILURES" envDefault:"5"`
+
+	// RetryBackoff is how long to wait before attempting a failed page
+	// again. It doubles with every consecutive failure.
+	RetryBackoff time.Duration `env:"RETRY_BACKOFF" envDefault:"15m"`
+
+	// SyncInterval is the time between syncs when running as a daemon.
+	SyncInterval time.Duration `env:"SYNC_INTERVAL" envDefault:"15m"`
+
+	// MetricsAddr is the address Prometheus metrics are served on when
+	// running as a daemon, e.g., ":9090". Metrics aren't served if empty.
+	MetricsAddr string `env:"METRICS_ADDR"`
+
+	// APIAddr is the address the HTTP API is served on when running as a
+	// daemon, e.g., "127.0.0.1:8080". The API isn't served if empty.
+	APIAddr string `env:"API_ADDR"`
+
+	// APIToken is a bearer token required by the HTTP API, if set.
+	APIToken string `env:"API_TOKEN"`
+
+	// WebhookURL is the URL notifications about syncs are sent to. No
+	// notifications are sent if empty.
+	WebhookURL string `env:"WEBHOOK_URL"`
+
+	// WebhookFormat is the payload format of notifications, one of
+	// "json", "slack", "discord" or "ntfy".
+	WebhookFormat string `env:"WEBHOOK_FORMAT" envDefault:"json"`
+
+	// WebhookEvents is the events notifications are sent for: "sync"
+	// (every completed sync), "failure" and "quarantine".
+	WebhookEvents []string `env:"WEBHOOK_EVENTS" envDefault:"failure,quarantine" envSeparator:","`
+
+	// WebhookTemplate is a Go template for the notification message,
+	// executed with the sync result. A default message is used if empty.
+	WebhookTemplate string `env:"WEBHOOK_TEMPLATE"`
+
+	// WebhookRetries is the number of times delivering a notification is
+	// retried.
+	WebhookRetries int `env:"WEBHOOK_RETRIES" envDefault:"3"`
+
+	// StatePath is the file the state is stored in. When empty, the state
+	// is stored in StateDir, or searched for in the state directories and
+	// the current working directory if that's empty too.
+	StatePath string `env:"STATE_PATH"`
+
+	// StateDir is the directory the state is stored in, under the default
+	// file name of StateBackend. Set for profiles.
+	StateDir string `env:"STATE_DIR"`
+
+	// StateBackend is how the state is stored, either "yaml" (a YAML
+	// file) or "bolt" (an embedded database, better suited to many pages
+	// and keeping the history of attempts).
+	StateBackend string `env:"STATE_BACKEND" envDefault:"yaml"`
+
+	// TombstoneTTL is how long pages that were removed from their
+	// document are remembered, so that they aren't synced again if they
+	// come back. Zero remembers them forever.
+	TombstoneTTL time.Duration `env:"TOMBSTONE_TTL" envDefault:"2160h"`
+
+	// CacheDir is where documents and page files downloaded from the
+	// cloud are cached. Defaults to the user's cache directory.
+	CacheDir string `env:"CACHE_DIR"`
+
+	// CacheMaxSize is the maximum size of the document cache in MiB. Zero
+	// disables the limit.
+	CacheMaxSize int64 `env:"CACHE_MAX_SIZE" envDefault:"512"`
+
+	// Profile is the name of the profile in use, see [Load]. Empty for the
+	// default one.
+	Profile string `env:"-"`
+
+	// EnvFile is the environment file the configuration was loaded from.
+	// Empty if none was found.
+	EnvFile string `env:"-"`
 }
 
+// ProfileEnv is the environment variable selecting the profile when none
+// is passed to [Load].
+const ProfileEnv = "REMARKABLEDAYONE_PROFILE"
+
+// profileName matches valid profile names.
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
 // Load returns an initialized [Config] based on the current environment
 // read from the ENV environment variable.
-func Load(_ *slog.Logger) (*Config, error) {
+//
+// If a profile is provided, or set with [ProfileEnv], the configuration
+// is instead read from the profile's own environment file and the state,
+// cache and tokens are kept in the profile's own directories, unless set
+// explicitly. This keeps profiles, e.g., one for testing, from affecting
+// each other.
+func Load(_ *slog.Logger, profile string) (*Config, error) {
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+
 	environment := strings.ToLower(os.Getenv("ENV"))
 
 	var envFile string
-	switch environment {
-	case "dev", "development":
+	switch {
+	case profile != "":
+		if !profileName.MatchString(profile) {
+			return nil, fmt.Errorf("invalid profile name %q", profile)
+		}
+		dir, err := profileConfigDir(profile)
+		if err != nil {
+			return nil, err
+		}
+		envFile = filepath.Join(dir, ".env")
+	case environment == "dev" || environment == "development":
 		envFile = ".env.development"
-	case "prod", "production":
+	case environment == "prod" || environment == "production":
 		envFile = ".env.production"
 	default:
 		envFile = ".env"
 	}
 
 	// If there's an environment file, load it.
-	if envFile != "" {
-		if _, err := os.Stat(envFile); err == nil {
-			if err := godotenv.Load(envFile); err != nil {
-				return nil, err
-			}
-		} else if !os.IsNotExist(err) {
+	if _, err := os.Stat(envFile); err == nil {
+		if err := godotenv.Load(envFile); err != nil {
 			return nil, err
 		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	} else {
+		envFile = ""
 	}
 
-	cfg := &Config{}
+	cfg := &Config{Profile: profile, EnvFile: envFile}
 	if err := env.Parse(cfg); err != nil {
 		return nil, err
 	}
 
+	if profile != "" {
+		if err := cfg.applyProfile(); err != nil {
+			return nil, err
+		}
+	}
+
 	return cfg, nil
 }
+
+// applyProfile sets the paths that weren't set explicitly to the
+// directories of the profile.
+func (c *Config) applyProfile() error {
+	configDir, err := profileConfigDir(c.Profile)
+	if err != nil {
+		return err
+	}
+	if c.TokenPath == "" {
+		c.TokenPath = filepath.Join(configDir, "tokens.yml")
+	}
+
+	if c.StatePath == "" && c.StateDir == "" {
+		stateHome := os.Getenv("XDG_STATE_HOME")
+		if stateHome == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+			stateHome = filepath.Join(homeDir, ".local", "state")
+		}
+		c.StateDir = filepath.Join(stateHome, "remarkabledayone", "profiles", c.Profile)
+	}
+
+	if c.CacheDir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return err
+		}
+		c.CacheDir = filepath.Join(cacheDir, "remarkabledayone", "profiles", c.Profile)
+	}
+	return nil
+}
+
+// profileConfigDir returns the directory holding the environment file
+// and tokens of the provided profile.
+func profileConfigDir(profile string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "remarkabledayone", "profiles", profile), nil
+}
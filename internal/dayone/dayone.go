@@ -20,26 +20,68 @@
 package dayone
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"os"
 	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/metrics"
 )
 
-// EntryFromPNG creates a new DayOne entry from a PNG file.
-func EntryFromPNG(src, title string, tags []string) error {
-	args := []string{"--attachments", src}
+// entryIDPattern matches the ID of a created entry in the output of the
+// dayone2 CLI.
+var entryIDPattern = regexp.MustCompile(`(?i)uuid:\s*([0-9A-F]+)`)
+
+// EntryFromPNGs creates a new DayOne entry from one or more PNG files.
+// If body is not empty, it is placed between the title and the
+// attachments. The dayone2 CLI is killed if the context is cancelled.
+// Returns the ID of the created entry, empty if it couldn't be
+// determined.
+func EntryFromPNGs(ctx context.Context, srcs []string, title, body string, tags []string) (string, error) {
+	args := append([]string{"--attachments"}, srcs...)
 
 	if len(tags) > 0 {
 		args = append(args, "--tags")
 		args = append(args, tags...)
-		args = append(args, "--")
 	}
 
-	// Add the new, title and attachment arguments.
-	args = append(args, "new", title, "[{attachment}]")
+	// Both attachments and tags accept multiple values, so terminate them
+	// before the command.
+	args = append(args, "--")
+
+	// Add the new, title, body and attachment arguments.
+	text := []string{title}
+	if body != "" {
+		text = append(text, body)
+	}
+	text = append(text, strings.TrimSpace(strings.Repeat("[{attachment}] ", len(srcs))))
+	args = append(args, "new", strings.Join(text, "\n\n"))
 
 	//#nosec:G204 // Why: Safe for our usecase.
-	cmd := exec.Command("dayone2", args...)
-	cmd.Stdout = os.Stdout
+	cmd := exec.CommandContext(ctx, "dayone2", args...)
+	var out bytes.Buffer
+	// Stdout is reserved for the sync summary, so the output of dayone2
+	// goes to stderr.
+	cmd.Stdout = io.MultiWriter(os.Stderr, &out)
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+
+	start := time.Now()
+	err := cmd.Run()
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	metrics.EntryDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return "", err
+	}
+
+	if m := entryIDPattern.FindSubmatch(out.Bytes()); m != nil {
+		return string(m[1]), nil
+	}
+	return "", nil
 }
@@ -0,0 +1,138 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package metrics contains the Prometheus metrics exposed by the
+// remarkabledayone utility when running as a daemon.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace is the prefix of every metric.
+const namespace = "remarkabledayone"
+
+// Registry contains every metric of the utility, along with Go runtime
+// and process metrics.
+var Registry = prometheus.NewRegistry()
+
+// Contains the metrics of the utility.
+var (
+	// SyncRuns counts sync runs by their outcome: "success", "partial" or
+	// "failure".
+	SyncRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_runs_total",
+		Help:      "Number of sync runs by outcome.",
+	}, []string{"result"})
+
+	// PagesSynced counts pages an entry was created for.
+	PagesSynced = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pages_synced_total",
+		Help:      "Number of pages an entry was created for.",
+	})
+
+	// PagesFailed counts pages that failed to sync.
+	PagesFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pages_failed_total",
+		Help:      "Number of pages that failed to sync.",
+	})
+
+	// RenderDuration observes how long rendering a page takes, including
+	// handwriting recognition.
+	RenderDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "page_render_duration_seconds",
+		Help:      "Time spent rendering a page.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	})
+
+	// CloudRequestDuration observes the latency of reMarkable cloud
+	// requests by operation.
+	CloudRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cloud_request_duration_seconds",
+		Help:      "Latency of reMarkable cloud requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	// EntryDuration observes how long creating a journal entry takes by
+	// outcome: "success" or "failure".
+	EntryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "journal_entry_duration_seconds",
+		Help:      "Time spent creating a journal entry.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	// LastSuccess is the time of the last sync where no page failed.
+	LastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful sync.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SyncRuns, PagesSynced, PagesFailed, RenderDuration, CloudRequestDuration, EntryDuration, LastSuccess,
+	)
+}
+
+// ObserveCloudRequest records the latency of a cloud request that
+// started at the provided time. Meant to be deferred.
+func ObserveCloudRequest(operation string, start time.Time) {
+	CloudRequestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// Handler returns an HTTP handler serving the metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves the metrics on "/metrics" at the provided address until
+// the context is cancelled.
+func Serve(ctx context.Context, log *slog.Logger, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx) //nolint:errcheck // Why: Best effort.
+	}()
+
+	log.Info("serving metrics", "addr", addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
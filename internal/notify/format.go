@@ -0,0 +1,100 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package notify
+
+import (
+	"encoding/json"
+
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// Format is the payload format of notifications.
+type Format string
+
+// Contains the supported formats.
+const (
+	// FormatJSON sends the event, message and sync result as JSON.
+	FormatJSON Format = "json"
+
+	// FormatSlack sends a Slack incoming webhook message.
+	FormatSlack Format = "slack"
+
+	// FormatDiscord sends a Discord webhook message.
+	FormatDiscord Format = "discord"
+
+	// FormatNtfy publishes the message to an ntfy topic URL.
+	FormatNtfy Format = "ntfy"
+)
+
+// formats is the supported formats.
+var formats = []Format{FormatJSON, FormatSlack, FormatDiscord, FormatNtfy}
+
+// discordMaxLength is the maximum length of a Discord message.
+const discordMaxLength = 2000
+
+// payload is the body and headers of a notification request.
+type payload struct {
+	body   []byte
+	header map[string]string
+}
+
+// JSONPayload is the body sent by the "json" format.
+type JSONPayload struct {
+	// Event is the event the notification is sent for.
+	Event Event `json:"event"`
+
+	// Message is the templated message.
+	Message string `json:"message"`
+
+	// Result is the result of the sync.
+	Result *syncer.Result `json:"result"`
+}
+
+// payload creates the request for a notification in the format.
+func (f Format) payload(ev Event, msg string, res *syncer.Result) (*payload, error) {
+	var v any
+	switch f {
+	case FormatSlack:
+		v = map[string]string{"text": msg}
+	case FormatDiscord:
+		if r := []rune(msg); len(r) > discordMaxLength {
+			msg = string(r[:discordMaxLength-1]) + "…"
+		}
+		v = map[string]string{"content": msg}
+	case FormatNtfy:
+		// ntfy uses the body as the message and headers for the rest.
+		p := &payload{body: []byte(msg), header: map[string]string{
+			"Content-Type": "text/plain; charset=utf-8",
+			"Title":        "remarkabledayone: " + res.Document,
+			"Tags":         "notebook",
+		}}
+		if ev != EventSync {
+			p.header["Priority"] = "high"
+			p.header["Tags"] = "warning"
+		}
+		return p, nil
+	default:
+		v = &JSONPayload{Event: ev, Message: msg, Result: res}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &payload{body: b, header: map[string]string{"Content-Type": "application/json"}}, nil
+}
@@ -0,0 +1,246 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package notify implements sending notifications about syncs to
+// webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// Event is something a notification is sent for.
+type Event string
+
+// Contains the supported events, from least to most important.
+const (
+	// EventSync is a sync that completed, whatever its outcome.
+	EventSync Event = "sync"
+
+	// EventFailure is a sync that failed or had failing pages.
+	EventFailure Event = "failure"
+
+	// EventQuarantine is a sync that quarantined pages.
+	EventQuarantine Event = "quarantine"
+)
+
+// events is the supported events, from least to most important.
+var events = []Event{EventSync, EventFailure, EventQuarantine}
+
+// DefaultTemplate is the default template of notification messages.
+const DefaultTemplate = `{{if .Error}}Syncing {{.Document}} failed: {{.Error}}` +
+	`{{else}}Synced {{.Document}}: {{len .Created}} created, {{len .Failed}} failed, {{len .Skipped}} skipped{{end}}` +
+	`{{range .Quarantined}}
+Page {{.Page}} was quarantined: {{.Reason}}{{end}}`
+
+// requestTimeout is the maximum time a single delivery attempt can take.
+const requestTimeout = 30 * time.Second
+
+// Data is what message templates are executed with.
+type Data struct {
+	// Event is the event the notification is sent for.
+	Event Event
+
+	// Result is the result of the sync.
+	*syncer.Result
+}
+
+// Notifier sends notifications to a webhook. Create with [New].
+type Notifier struct {
+	log *slog.Logger
+
+	// URL is the webhook notifications are sent to.
+	URL string
+
+	// Format is the payload format.
+	Format Format
+
+	// Events is the events notifications are sent for.
+	Events []Event
+
+	// Template is the template of the message.
+	Template *template.Template
+
+	// Retries is the number of times delivery is retried.
+	Retries int
+
+	// Backoff is the wait before the first retry. It doubles with every
+	// retry.
+	Backoff time.Duration
+
+	// Client is the HTTP client to use.
+	Client *http.Client
+}
+
+// New creates a [Notifier] from the configuration. Returns nil if no
+// webhook is configured.
+func New(log *slog.Logger, cfg *config.Config) (*Notifier, error) {
+	if cfg.WebhookURL == "" {
+		return nil, nil
+	}
+
+	format := Format(cfg.WebhookFormat)
+	if !slices.Contains(formats, format) {
+		return nil, fmt.Errorf("unknown webhook format %q", cfg.WebhookFormat)
+	}
+
+	evs := make([]Event, 0, len(cfg.WebhookEvents))
+	for _, e := range cfg.WebhookEvents {
+		ev := Event(strings.TrimSpace(e))
+		if !slices.Contains(events, ev) {
+			return nil, fmt.Errorf("unknown webhook event %q", e)
+		}
+		evs = append(evs, ev)
+	}
+
+	text := cfg.WebhookTemplate
+	if text == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("webhook").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+
+	return &Notifier{
+		log:      log,
+		URL:      cfg.WebhookURL,
+		Format:   format,
+		Events:   evs,
+		Template: tmpl,
+		Retries:  max(cfg.WebhookRetries, 0),
+		Backoff:  time.Second,
+		Client:   &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// event returns the most important event of the result that
+// notifications are sent for. Empty if there's none.
+func (n *Notifier) event(res *syncer.Result) Event {
+	var matched []Event
+	matched = append(matched, EventSync)
+	if res.Error != "" || len(res.Failed) > 0 {
+		matched = append(matched, EventFailure)
+	}
+	if len(res.Quarantined) > 0 {
+		matched = append(matched, EventQuarantine)
+	}
+
+	for _, ev := range slices.Backward(matched) {
+		if slices.Contains(n.Events, ev) {
+			return ev
+		}
+	}
+	return ""
+}
+
+// Notify sends a notification about the result of a sync, if it matches
+// one of the configured events. Nothing is sent if n is nil.
+func (n *Notifier) Notify(ctx context.Context, res *syncer.Result) error {
+	if n == nil {
+		return nil
+	}
+
+	ev := n.event(res)
+	if ev == "" {
+		return nil
+	}
+
+	var msg strings.Builder
+	if err := n.Template.Execute(&msg, &Data{Event: ev, Result: res}); err != nil {
+		return fmt.Errorf("failed to execute webhook template: %w", err)
+	}
+
+	p, err := n.Format.payload(ev, strings.TrimSpace(msg.String()), res)
+	if err != nil {
+		return err
+	}
+
+	wait := n.Backoff
+	for attempt := 0; ; attempt++ {
+		err := n.send(ctx, p)
+		if err == nil {
+			n.log.Debug("sent notification", "event", ev)
+			return nil
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) || attempt >= n.Retries {
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+
+		n.log.With("error", err, "attempt", attempt+1).Warn("failed to send notification, retrying", "in", wait)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// permanentError is a delivery failure that won't succeed when retried.
+type permanentError struct {
+	err error
+}
+
+// Error implements [error].
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// send makes a single delivery attempt. Client errors other than rate
+// limiting are returned as a [permanentError].
+func (n *Notifier) send(ctx context.Context, p *payload) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(p.body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	for k, v := range p.header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck // Why: Best effort.
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:errcheck // Why: Only for the error.
+	err = fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
@@ -0,0 +1,252 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/syncer"
+)
+
+// request is a request received by a stub webhook.
+type request struct {
+	header http.Header
+	body   string
+}
+
+// stub is a webhook that responds with the provided statuses in order,
+// the last one repeated, and records the requests it received.
+type stub struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+// newStub creates a new [stub], closed when the test ends.
+func newStub(t *testing.T, statuses ...int) *stub {
+	s := &stub{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request: %v", err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, request{r.Header.Clone(), string(b)})
+		status := s.statuses[min(len(s.requests), len(s.statuses))-1]
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newNotifier creates a [Notifier] sending to the stub.
+func newNotifier(t *testing.T, s *stub, format Format, events string) *Notifier {
+	t.Helper()
+	n, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)), &config.Config{
+		WebhookURL:     s.URL,
+		WebhookFormat:  string(format),
+		WebhookEvents:  strings.Split(events, ","),
+		WebhookRetries: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Backoff = time.Millisecond
+	return n
+}
+
+// failedResult returns the result of a sync with a failed page.
+func failedResult() *syncer.Result {
+	res := syncer.NewResult("Journal")
+	res.Created = append(res.Created, syncer.PageResult{ID: "a", Page: 1})
+	res.Failed = append(res.Failed, syncer.PageResult{ID: "b", Page: 2, Reason: "boom"})
+	return res
+}
+
+func TestNotifyFormats(t *testing.T) {
+	const msg = "Synced Journal: 1 created, 1 failed, 0 skipped"
+
+	tests := []struct {
+		format Format
+		header map[string]string
+		check  func(t *testing.T, body string)
+	}{
+		{
+			format: FormatJSON,
+			header: map[string]string{"Content-Type": "application/json"},
+			check: func(t *testing.T, body string) {
+				var p struct {
+					Event   Event  `json:"event"`
+					Message string `json:"message"`
+					Result  struct {
+						Document string              `json:"document"`
+						Failed   []syncer.PageResult `json:"failed"`
+					} `json:"result"`
+				}
+				if err := json.Unmarshal([]byte(body), &p); err != nil {
+					t.Fatal(err)
+				}
+				if p.Event != EventFailure || p.Message != msg || p.Result.Document != "Journal" ||
+					len(p.Result.Failed) != 1 || p.Result.Failed[0].Reason != "boom" {
+					t.Errorf("unexpected payload: %s", body)
+				}
+			},
+		},
+		{
+			format: FormatSlack,
+			header: map[string]string{"Content-Type": "application/json"},
+			check: func(t *testing.T, body string) {
+				if want := `{"text":"` + msg + `"}`; body != want {
+					t.Errorf("body = %s, want %s", body, want)
+				}
+			},
+		},
+		{
+			format: FormatDiscord,
+			header: map[string]string{"Content-Type": "application/json"},
+			check: func(t *testing.T, body string) {
+				if want := `{"content":"` + msg + `"}`; body != want {
+					t.Errorf("body = %s, want %s", body, want)
+				}
+			},
+		},
+		{
+			format: FormatNtfy,
+			header: map[string]string{
+				"Content-Type": "text/plain; charset=utf-8",
+				"Title":        "remarkabledayone: Journal",
+				"Tags":         "warning",
+				"Priority":     "high",
+			},
+			check: func(t *testing.T, body string) {
+				if body != msg {
+					t.Errorf("body = %q, want %q", body, msg)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			s := newStub(t, http.StatusOK)
+			n := newNotifier(t, s, tt.format, "failure")
+			if err := n.Notify(context.Background(), failedResult()); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(s.requests) != 1 {
+				t.Fatalf("got %d requests, want 1", len(s.requests))
+			}
+			req := s.requests[0]
+			for k, v := range tt.header {
+				if got := req.header.Get(k); got != v {
+					t.Errorf("header %s = %q, want %q", k, got, v)
+				}
+			}
+			tt.check(t, req.body)
+		})
+	}
+}
+
+func TestNotifyDiscordTruncates(t *testing.T) {
+	s := newStub(t, http.StatusOK)
+	n := newNotifier(t, s, FormatDiscord, "failure")
+	res := failedResult()
+	res.Error = strings.Repeat("x", 3*discordMaxLength)
+	if err := n.Notify(context.Background(), res); err != nil {
+		t.Fatal(err)
+	}
+
+	var p struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(s.requests[0].body), &p); err != nil {
+		t.Fatal(err)
+	}
+	if n := len([]rune(p.Content)); n != discordMaxLength {
+		t.Errorf("message is %d characters, want %d", n, discordMaxLength)
+	}
+}
+
+func TestNotifyEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		events string
+		res    *syncer.Result
+		want   int
+	}{
+		{"not subscribed", "quarantine", failedResult(), 0},
+		{"subscribed", "failure", failedResult(), 1},
+		{"successful sync", "failure,quarantine", syncer.NewResult("Journal"), 0},
+		{"every sync", "sync", syncer.NewResult("Journal"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStub(t, http.StatusOK)
+			n := newNotifier(t, s, FormatJSON, tt.events)
+			if err := n.Notify(context.Background(), tt.res); err != nil {
+				t.Fatal(err)
+			}
+			if len(s.requests) != tt.want {
+				t.Errorf("got %d requests, want %d", len(s.requests), tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int
+		wantErr  bool
+	}{
+		{"success", []int{http.StatusNoContent}, 1, false},
+		{"server error", []int{http.StatusInternalServerError, http.StatusOK}, 2, false},
+		{"rate limited", []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}, 3, false},
+		{"client error", []int{http.StatusBadRequest, http.StatusOK}, 1, true},
+		{"not found", []int{http.StatusNotFound, http.StatusOK}, 1, true},
+		{"retries exhausted", []int{http.StatusBadGateway}, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStub(t, tt.statuses...)
+			n := newNotifier(t, s, FormatJSON, "failure")
+			err := n.Notify(context.Background(), failedResult())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Notify() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(s.requests) != tt.requests {
+				t.Errorf("got %d requests, want %d", len(s.requests), tt.requests)
+			}
+		})
+	}
+}
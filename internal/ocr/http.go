@@ -0,0 +1,162 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// HTTPInput is the type of input sent to a recognition server.
+type HTTPInput string
+
+// Contains the supported [HTTPInput] values.
+const (
+	// HTTPInputImage sends the rendered images of the page.
+	HTTPInputImage HTTPInput = "image"
+
+	// HTTPInputStrokes sends the raw ".rm" file of the page.
+	HTTPInputStrokes HTTPInput = "strokes"
+)
+
+// HTTPRequest is the JSON body sent to a recognition server. Binary
+// data is base64 encoded.
+type HTTPRequest struct {
+	// PageID is the ID of the page being recognized.
+	PageID string `json:"page_id"`
+
+	// Strokes is the contents of the page's v6 ".rm" file. Only set when
+	// using [HTTPInputStrokes].
+	Strokes []byte `json:"strokes,omitempty"`
+
+	// Images is the list of rendered PNGs of the page. Only set when
+	// using [HTTPInputImage].
+	Images [][]byte `json:"images,omitempty"`
+
+	// Language is the language hint for the server, if configured.
+	Language string `json:"language,omitempty"`
+}
+
+// HTTPResponse is the JSON body expected from a recognition server.
+type HTTPResponse struct {
+	// Text is the recognized text.
+	Text string `json:"text"`
+}
+
+// HTTP recognizes text by sending pages to a recognition server over
+// HTTP. See [HTTPRequest] and [HTTPResponse] for the protocol.
+type HTTP struct {
+	// URL is the endpoint pages are POSTed to.
+	URL string
+
+	// Token is sent as a bearer token, if set.
+	Token string
+
+	// Input is the type of input to send.
+	Input HTTPInput
+
+	// Language is sent as a hint to the server, if set.
+	Language string
+
+	// Client is the HTTP client to use.
+	Client *http.Client
+}
+
+// NewHTTP creates a new [HTTP] provider. Requests are cancelled after
+// the provided timeout.
+func NewHTTP(url, token string, input HTTPInput, language string, timeout time.Duration) (*HTTP, error) {
+	switch input {
+	case HTTPInputImage, HTTPInputStrokes:
+	case "":
+		input = HTTPInputImage
+	default:
+		return nil, fmt.Errorf("unknown recognition input %q", input)
+	}
+	if url == "" {
+		return nil, fmt.Errorf("recognition server URL is required")
+	}
+
+	return &HTTP{
+		URL:      url,
+		Token:    token,
+		Input:    input,
+		Language: language,
+		Client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Recognize implements [Provider].
+func (h *HTTP) Recognize(ctx context.Context, in *Input) (string, error) {
+	req := HTTPRequest{PageID: in.PageID, Language: h.Language}
+	switch h.Input {
+	case HTTPInputStrokes:
+		b, err := os.ReadFile(in.RMPath)
+		if err != nil {
+			return "", err
+		}
+		req.Strokes = b
+	case HTTPInputImage:
+		for _, p := range in.PNGPaths {
+			//#nosec:G304 // Why: Safe for our usecase.
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return "", err
+			}
+			req.Images = append(req.Images, b)
+		}
+	}
+
+	body, err := json.Marshal(&req)
+	if err != nil {
+		return "", err
+	}
+
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("Accept", "application/json")
+	if h.Token != "" {
+		hreq.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+
+	resp, err := h.Client.Do(hreq)
+	if err != nil {
+		return "", fmt.Errorf("failed to call recognition server: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Why: Best effort.
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:errcheck // Why: Only for the error.
+		return "", fmt.Errorf("recognition server returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var out HTTPResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode recognition response: %w", err)
+	}
+
+	return out.Text, nil
+}
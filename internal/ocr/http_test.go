@@ -0,0 +1,146 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes contents to a file in a temporary directory, returning
+// its path.
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHTTPRecognize(t *testing.T) {
+	in := &Input{
+		PageID:   "page",
+		RMPath:   writeFile(t, "page.rm", "strokes"),
+		PNGPaths: []string{writeFile(t, "a.png", "image-a"), writeFile(t, "b.png", "image-b")},
+	}
+
+	tests := []struct {
+		name    string
+		input   HTTPInput
+		handler http.HandlerFunc
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "images",
+			input: HTTPInputImage,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				var req HTTPRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if len(req.Images) != 2 || !bytes.Equal(req.Images[1], []byte("image-b")) || req.Strokes != nil {
+					t.Errorf("unexpected request: %+v", req)
+				}
+				w.Write([]byte(`{"text": "hello"}`)) //nolint:errcheck // Why: Test.
+			},
+			want: "hello",
+		},
+		{
+			name:  "strokes",
+			input: HTTPInputStrokes,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				var req HTTPRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if string(req.Strokes) != "strokes" || req.Images != nil {
+					t.Errorf("unexpected request: %+v", req)
+				}
+				w.Write([]byte(`{"text": "world"}`)) //nolint:errcheck // Why: Test.
+			},
+			want: "world",
+		},
+		{
+			name:  "error status",
+			input: HTTPInputImage,
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+			},
+			wantErr: "503 Service Unavailable: model not loaded",
+		},
+		{
+			name:  "invalid response",
+			input: HTTPInputImage,
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Write([]byte(`not json`)) //nolint:errcheck // Why: Test.
+			},
+			wantErr: "failed to decode recognition response",
+		},
+		{
+			name:  "timeout",
+			input: HTTPInputImage,
+			handler: func(_ http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+			wantErr: "failed to call recognition server",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "Bearer token" {
+					t.Errorf("Authorization = %q, want the token", got)
+				}
+				tt.handler(w, r)
+			}))
+			defer srv.Close()
+
+			h, err := NewHTTP(srv.URL, "token", tt.input, "eng", 100*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := h.Recognize(context.Background(), in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Recognize() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Recognize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
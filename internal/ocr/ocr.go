@@ -0,0 +1,113 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package ocr implements handwriting recognition for rendered pages.
+package ocr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// Input is a page to recognize handwriting on.
+type Input struct {
+	// PageID is the ID of the page.
+	PageID string
+
+	// RMPath is the path to the page's ".rm" file, containing the raw
+	// stroke data.
+	RMPath string
+
+	// PNGPaths is the list of rendered images of the page.
+	PNGPaths []string
+}
+
+// Provider recognizes handwriting on a page.
+type Provider interface {
+	// Recognize returns the text on the provided page.
+	Recognize(ctx context.Context, in *Input) (string, error)
+}
+
+// Cache stores the results of recognition by a hash of the page's
+// contents.
+type Cache interface {
+	// Get returns the text recognized for the page, if it was recognized
+	// when it had the provided hash.
+	Get(pageID, hash string) (string, bool)
+
+	// Put stores the text recognized for a page with the provided hash.
+	Put(pageID, hash, text string)
+}
+
+// cachedProvider is a [Provider] that caches the results of another
+// [Provider].
+type cachedProvider struct {
+	p       Provider
+	c       Cache
+	options string
+}
+
+// WithCache returns a [Provider] that only calls p for pages that
+// haven't been recognized before, based on a hash of their stroke data
+// and the provided options. options should describe everything else that
+// changes the result, e.g., the provider, its language and how pages are
+// rendered, so that changing them recognizes pages again.
+func WithCache(p Provider, c Cache, options string) Provider {
+	return &cachedProvider{p, c, options}
+}
+
+// Recognize implements [Provider].
+func (c *cachedProvider) Recognize(ctx context.Context, in *Input) (string, error) {
+	hash, err := hashPage(c.options, in.RMPath)
+	if err != nil {
+		return "", err
+	}
+
+	if text, ok := c.c.Get(in.PageID, hash); ok {
+		return text, nil
+	}
+
+	text, err := c.p.Recognize(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	c.c.Put(in.PageID, hash, text)
+
+	return text, nil
+}
+
+// hashPage returns the hex encoded SHA-256 hash of the provided options
+// and the file at path.
+func hashPage(options, path string) (string, error) {
+	//#nosec:G304 // Why: Safe for our usecase.
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck // Why: Best effort.
+
+	h := sha256.New()
+	h.Write([]byte(options)) //nolint:errcheck // Why: Never fails.
+	h.Write([]byte{0})       //nolint:errcheck // Why: Never fails.
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
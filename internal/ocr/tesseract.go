@@ -0,0 +1,163 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jaredallard/cmdexec"
+)
+
+// Tesseract recognizes text using a local install of tesseract.
+type Tesseract struct {
+	// Language is the tesseract language (or languages, joined with "+")
+	// to recognize, e.g., "eng".
+	Language string
+
+	// MinConfidence is the minimum confidence (0-100) a word must have to
+	// be included in the result.
+	MinConfidence float64
+}
+
+// NewTesseract creates a new [Tesseract], ensuring that tesseract is
+// installed.
+func NewTesseract(language string, minConfidence float64) (*Tesseract, error) {
+	if _, err := cmdexec.LookPath("tesseract"); err != nil {
+		return nil, fmt.Errorf("tesseract is required for OCR: %w", err)
+	}
+
+	return &Tesseract{Language: language, MinConfidence: minConfidence}, nil
+}
+
+// Recognize implements [Provider]. Each rendered image of the page is
+// recognized separately and the results joined.
+func (t *Tesseract) Recognize(ctx context.Context, in *Input) (string, error) {
+	texts := make([]string, 0, len(in.PNGPaths))
+	for _, p := range in.PNGPaths {
+		text, err := t.recognizeImage(ctx, p)
+		if err != nil {
+			return "", err
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// recognizeImage returns the text on the PNG at the provided path.
+func (t *Tesseract) recognizeImage(ctx context.Context, path string) (string, error) {
+	args := []string{path, "stdout"}
+	if t.Language != "" {
+		args = append(args, "-l", t.Language)
+	}
+	args = append(args, "tsv")
+
+	var stdout bytes.Buffer
+	cmd := cmdexec.CommandContext(ctx, "tesseract", args...)
+	cmd.SetStdout(&stdout)
+	cmd.SetStderr(os.Stderr)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to run tesseract: %w", err)
+	}
+
+	return parseTSV(&stdout, t.MinConfidence)
+}
+
+// word is a single word recognized by tesseract.
+type word struct {
+	block, par, line int
+	text             string
+}
+
+// parseTSV converts tesseract's TSV output into text, dropping words
+// below the provided confidence. Lines are separated by a newline and
+// paragraphs by a blank line.
+func parseTSV(r io.Reader, minConfidence float64) (string, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = '\t'
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1
+
+	// level page_num block_num par_num line_num word_num left top width
+	// height conf text
+	const (
+		colLevel = 0
+		colBlock = 2
+		colPar   = 3
+		colLine  = 4
+		colConf  = 10
+		colText  = 11
+		levelWrd = "5"
+	)
+
+	var words []word
+	for i := 0; ; i++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse tesseract output: %w", err)
+		}
+		if i == 0 || len(rec) <= colText || rec[colLevel] != levelWrd {
+			continue
+		}
+
+		conf, err := strconv.ParseFloat(rec[colConf], 64)
+		if err != nil || conf < minConfidence {
+			continue
+		}
+		text := strings.TrimSpace(rec[colText])
+		if text == "" {
+			continue
+		}
+
+		w := word{text: text}
+		w.block, _ = strconv.Atoi(rec[colBlock]) //nolint:errcheck // Why: Zero is fine.
+		w.par, _ = strconv.Atoi(rec[colPar])     //nolint:errcheck // Why: Zero is fine.
+		w.line, _ = strconv.Atoi(rec[colLine])   //nolint:errcheck // Why: Zero is fine.
+		words = append(words, w)
+	}
+
+	var sb strings.Builder
+	for i, w := range words {
+		if i > 0 {
+			prev := words[i-1]
+			switch {
+			case prev.block != w.block || prev.par != w.par:
+				sb.WriteString("\n\n")
+			case prev.line != w.line:
+				sb.WriteString("\n")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString(w.text)
+	}
+
+	return sb.String(), nil
+}
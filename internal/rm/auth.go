@@ -0,0 +1,296 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaredallard/remarkabledayone/internal/metrics"
+	"github.com/juruen/rmapi/config"
+	"github.com/juruen/rmapi/model"
+	"github.com/juruen/rmapi/transport"
+	"github.com/mattn/go-isatty"
+	"gopkg.in/yaml.v3"
+)
+
+// ConnectURL is where one-time codes for [Auth.Login] are generated.
+const ConnectURL = "https://my.remarkable.com/device/browser/connect"
+
+// deviceDesc is the device description we register as.
+const deviceDesc = "desktop-linux"
+
+// ErrNotAuthenticated is returned when there is no device token and we
+// aren't able to prompt for a one-time code.
+var ErrNotAuthenticated = fmt.Errorf(
+	"not authenticated with reMarkable, get a one-time code from %s and run "+
+		"\"remarkabledayone auth login --code <code>\"", ConnectURL,
+)
+
+// Auth manages the tokens used to authenticate with the reMarkable
+// cloud. Create with [NewAuth].
+type Auth struct {
+	// Path is where tokens are stored. The format is compatible with
+	// rmapi's configuration file.
+	Path string
+
+	// DeviceToken, if set, is used instead of the device token stored at
+	// Path.
+	DeviceToken string
+
+	// Interactive allows prompting for a one-time code on stdin if there
+	// is no device token.
+	Interactive bool
+}
+
+// NewAuth creates a new [Auth]. If path is empty, rmapi's configuration
+// file is used. Prompting is only allowed if stdin is a terminal.
+func NewAuth(path, deviceToken string) (*Auth, error) {
+	if path == "" {
+		var err error
+		if path, err = config.ConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	interactive := isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+	return &Auth{Path: path, DeviceToken: deviceToken, Interactive: interactive}, nil
+}
+
+// read reads the tokens stored at Path, if any.
+func (a *Auth) read() (*model.AuthTokens, error) {
+	tokens := &model.AuthTokens{}
+
+	//#nosec:G304 // Why: Safe for our usecase.
+	b, err := os.ReadFile(a.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(b, tokens); err != nil {
+			return nil, fmt.Errorf("failed to parse tokens at %s: %w", a.Path, err)
+		}
+	}
+	return tokens, nil
+}
+
+// load reads the tokens stored at Path, if any. DeviceToken takes
+// precedence over the stored device token.
+func (a *Auth) load() (*model.AuthTokens, error) {
+	tokens, err := a.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if a.DeviceToken != "" && a.DeviceToken != tokens.DeviceToken {
+		// The stored user token belongs to another device token.
+		tokens.DeviceToken = a.DeviceToken
+		tokens.UserToken = ""
+	}
+
+	return tokens, nil
+}
+
+// save writes the tokens to Path. If the device token was provided
+// through DeviceToken and differs from the stored one, nothing is
+// written, so that the stored tokens are kept for when it's unset.
+func (a *Auth) save(tokens *model.AuthTokens) error {
+	if a.DeviceToken != "" {
+		stored, err := a.read()
+		if err != nil {
+			return err
+		}
+		if stored.DeviceToken != tokens.DeviceToken {
+			return nil
+		}
+	}
+
+	b, err := yaml.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.Path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(a.Path, b, 0o600)
+}
+
+// Login registers a new device using a one-time code from [ConnectURL]
+// and stores the resulting device token. Fails if DeviceToken is set, as
+// the new device token wouldn't be used.
+func (a *Auth) Login(code string) error {
+	if a.DeviceToken != "" {
+		return errors.New("a device token is provided through REMARKABLE_DEVICE_TOKEN, unset it to log in")
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != 8 {
+		return fmt.Errorf("one-time code should be 8 characters, got %d", len(code))
+	}
+
+	httpCtx := transport.CreateHttpClientCtx(model.AuthTokens{})
+	req := model.DeviceTokenRequest{Code: code, DeviceDesc: deviceDesc, DeviceId: uuid.New().String()}
+	resp := transport.BodyString{}
+	if err := httpCtx.Post(transport.EmptyBearer, config.NewTokenDevice, req, &resp); err != nil {
+		return fmt.Errorf("failed to register device: %w", err)
+	}
+
+	return a.save(&model.AuthTokens{DeviceToken: resp.Content})
+}
+
+// httpCtx returns an authenticated HTTP context. A fresh user token is
+// requested every time. If there is no device token, a one-time code is
+// requested on stdin when [Auth.Interactive] is set, otherwise
+// [ErrNotAuthenticated] is returned.
+func (a *Auth) httpCtx() (*transport.HttpClientCtx, error) {
+	tokens, err := a.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if tokens.DeviceToken == "" {
+		if !a.Interactive {
+			return nil, ErrNotAuthenticated
+		}
+
+		fmt.Fprintf(os.Stderr, "Enter one-time code (go to %s): ", ConnectURL)
+		code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read one-time code: %w", err)
+		}
+		if err := a.Login(code); err != nil {
+			return nil, err
+		}
+		if tokens, err = a.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	httpCtx := transport.CreateHttpClientCtx(*tokens)
+	resp := transport.BodyString{}
+	start := time.Now()
+	err = httpCtx.Post(transport.DeviceBearer, config.NewUserDevice, nil, &resp)
+	metrics.ObserveCloudRequest("user_token", start)
+	if errors.Is(err, transport.ErrUnauthorized) {
+		return nil, fmt.Errorf("device token was rejected, it may have been revoked: %w", ErrNotAuthenticated)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to create user token: %w", err)
+	}
+
+	tokens.UserToken = resp.Content
+	httpCtx.Tokens.UserToken = resp.Content
+	if err := a.save(tokens); err != nil {
+		return nil, fmt.Errorf("failed to save tokens: %w", err)
+	}
+
+	return &httpCtx, nil
+}
+
+// AuthStatus describes the current authentication state.
+type AuthStatus struct {
+	// Path is where tokens are stored.
+	Path string
+
+	// DeviceTokenSource is where the device token came from, "file" or
+	// "env". Empty if there is no device token.
+	DeviceTokenSource string
+
+	// DeviceTokenExpires is when the device token expires. Zero if it
+	// doesn't expire or isn't known.
+	DeviceTokenExpires time.Time
+
+	// UserTokenExpires is when the last user token expires. Zero if there
+	// is no user token. User tokens are refreshed on every sync.
+	UserTokenExpires time.Time
+
+	// User is the email of the authenticated user, if known.
+	User string
+}
+
+// Status returns the current authentication state without contacting
+// the reMarkable cloud.
+func (a *Auth) Status() (*AuthStatus, error) {
+	tokens, err := a.load()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &AuthStatus{Path: a.Path}
+	if tokens.DeviceToken != "" {
+		st.DeviceTokenSource = "file"
+		if a.DeviceToken != "" {
+			st.DeviceTokenSource = "env"
+		}
+		if c, err := parseClaims(tokens.DeviceToken); err == nil {
+			st.DeviceTokenExpires = c.expires()
+		}
+	}
+	if tokens.UserToken != "" {
+		c, err := parseClaims(tokens.UserToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse user token: %w", err)
+		}
+		st.UserTokenExpires = c.expires()
+		st.User = c.Profile.Email
+	}
+
+	return st, nil
+}
+
+// claims is the subset of JWT claims we read from tokens.
+type claims struct {
+	Exp     int64 `json:"exp"`
+	Profile struct {
+		Email string `json:"email"`
+	} `json:"auth0-profile"`
+}
+
+// expires returns the expiry of the token, zero if it doesn't expire.
+func (c *claims) expires() time.Time {
+	if c.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.Exp, 0)
+}
+
+// parseClaims decodes the claims of a JWT without verifying it.
+func parseClaims(token string) (*claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT")
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &claims{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,300 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/metrics"
+)
+
+// blobEntry is an entry of a sync 1.5 index ("docSchema") file. The
+// root index lists documents, a document's index lists its files.
+type blobEntry struct {
+	// Hash is the hex encoded SHA-256 of the blob.
+	Hash string
+
+	// Name is the document ID in the root index, or the file name (e.g.,
+	// "<id>/<page>.rm") in a document's index.
+	Name string
+
+	// Size is the size of the blob in bytes.
+	Size int64
+}
+
+// parseIndex parses a sync 1.5 index file. Both schema version 3 and 4
+// are supported.
+func parseIndex(r io.Reader) ([]blobEntry, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty index")
+	}
+
+	switch schema := scanner.Text(); schema {
+	case "3":
+	case "4":
+		// Summary line, "0:.:<count>:<size>".
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("missing schema 4 summary line")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported index schema %q", schema)
+	}
+
+	var entries []blobEntry
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		// <hash>:<type>:<name>:<subfiles>:<size>
+		fields := strings.Split(line, ":")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("invalid index line %q", line)
+		}
+		size, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size in index line %q: %w", line, err)
+		}
+		entries = append(entries, blobEntry{Hash: fields[0], Name: fields[2], Size: size})
+	}
+
+	return entries, scanner.Err()
+}
+
+// wantBlob returns true if the file is needed to read the document with
+// the provided ID. Thumbnails, PDFs, etc. are skipped.
+func wantBlob(id, name string) bool {
+	switch name {
+	case id + ".metadata", id + ".content", id + ".pagedata":
+		return true
+	}
+	return strings.HasPrefix(name, id+"/") && strings.HasSuffix(name, ".rm")
+}
+
+// readIndex downloads and parses the index with the provided hash.
+func (c *Client) readIndex(hash, name string) ([]blobEntry, error) {
+	defer metrics.ObserveCloudRequest("index", time.Now())
+
+	r, err := c.blobs.GetReader(hash, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() //nolint:errcheck // Why: Best effort.
+
+	return parseIndex(r)
+}
+
+// fetchDocumentBlobs downloads the files of the document with the
+// provided ID into destDir, laid out like the device's document tree.
+// When [Client.BlobDir] is set, files whose hash hasn't changed since
+// the last download are copied from it instead of being downloaded. The
+// context is checked between files.
+func (c *Client) fetchDocumentBlobs(ctx context.Context, id, destDir string) error {
+	start := time.Now()
+	rootHash, _, err := c.blobs.GetRootIndex()
+	metrics.ObserveCloudRequest("root", start)
+	if err != nil {
+		return fmt.Errorf("failed to get root index: %w", err)
+	}
+	root, err := c.readIndex(rootHash, "root.docSchema")
+	if err != nil {
+		return fmt.Errorf("failed to read root index: %w", err)
+	}
+
+	var docHash string
+	for _, e := range root {
+		if e.Name == id {
+			docHash = e.Hash
+			break
+		}
+	}
+	if docHash == "" {
+		return fmt.Errorf("document %s not found in root index", id)
+	}
+
+	files, err := c.readIndex(docHash, id+".docSchema")
+	if err != nil {
+		return fmt.Errorf("failed to read document index: %w", err)
+	}
+
+	var blobDir string
+	if c.BlobDir != "" {
+		blobDir = filepath.Join(c.BlobDir, filepath.Base(id))
+		if err := os.MkdirAll(blobDir, 0o750); err != nil {
+			return err
+		}
+	}
+
+	var downloaded, reused, skipped int
+	hashes := make(map[string]struct{}, len(files))
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !wantBlob(id, f.Name) {
+			continue
+		}
+		hashes[f.Hash] = struct{}{}
+
+		dest, err := sanitizeArchivePath(destDir, f.Name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+			return err
+		}
+
+		cached := dest
+		if blobDir != "" {
+			cached = filepath.Join(blobDir, filepath.Base(f.Hash))
+		}
+
+		switch ok, err := hasHash(cached, f.Hash); {
+		case blobDir != "" && !c.refresh && err == nil && ok:
+			reused++
+		case !c.refresh && c.unchanged(f):
+			// An empty file marks the page as not downloaded.
+			if err := os.WriteFile(dest, nil, 0o600); err != nil {
+				return err
+			}
+			skipped++
+			continue
+		default:
+			if err := c.downloadBlob(f, cached); err != nil {
+				return err
+			}
+			downloaded++
+		}
+		if cached != dest {
+			if err := copyFile(cached, dest); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.log.Info("fetched document files", "id", id, "downloaded", downloaded, "unchanged", reused,
+		"skipped", skipped)
+
+	if blobDir != "" {
+		pruneBlobs(blobDir, hashes)
+
+		// Used for eviction, see [Cache.BlobDir].
+		now := time.Now()
+		os.Chtimes(blobDir, now, now) //nolint:errcheck // Why: Best effort.
+	}
+
+	return nil
+}
+
+// unchanged returns true if the file is a page that doesn't need to be
+// downloaded, see [Client.Unchanged].
+func (c *Client) unchanged(f blobEntry) bool {
+	if c.Unchanged == nil || !strings.HasSuffix(f.Name, ".rm") {
+		return false
+	}
+	return c.Unchanged(strings.TrimSuffix(filepath.Base(f.Name), ".rm"), f.Hash)
+}
+
+// downloadBlob downloads a blob to dest, verifying its hash. The blob is
+// written to a temporary file first so that partial blobs are never
+// read.
+func (c *Client) downloadBlob(f blobEntry, dest string) error {
+	defer metrics.ObserveCloudRequest("blob", time.Now())
+
+	r, err := c.blobs.GetReader(f.Hash, filepath.Base(f.Name))
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", f.Name, err)
+	}
+	defer r.Close() //nolint:errcheck // Why: Best effort.
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*"+tmpSuffix)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // Why: Best effort, no-op once renamed.
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		tmp.Close() //nolint:errcheck // Why: Best effort.
+		return fmt.Errorf("failed to download %s: %w", f.Name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != f.Hash {
+		return fmt.Errorf("hash mismatch for %s: expected %s, got %s", f.Name, f.Hash, got)
+	}
+
+	return os.Rename(tmp.Name(), dest)
+}
+
+// hasHash returns true if the file at path exists and has the provided
+// hash.
+func hasHash(path, hash string) (bool, error) {
+	got, err := fileHash(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return got == hash, nil
+}
+
+// fileHash returns the hex encoded SHA-256 of the file at path, which is
+// also its hash in the cloud.
+func fileHash(path string) (string, error) {
+	//#nosec:G304 // Why: Safe for our usecase.
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck // Why: Best effort.
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pruneBlobs removes the blobs in dir whose hash isn't in keep. Best
+// effort, failures only waste disk space.
+func pruneBlobs(dir string, keep map[string]struct{}) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if _, ok := keep[f.Name()]; !ok {
+			os.Remove(filepath.Join(dir, f.Name())) //nolint:errcheck // Why: Best effort.
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tmpSuffix marks cache entries that are still being written.
+const tmpSuffix = ".tmp"
+
+// Cache is a [Source] that keeps downloaded documents on disk and reuses
+// them while the document is unchanged. Create with [NewCache].
+type Cache struct {
+	log *slog.Logger
+
+	// Source is where documents are fetched from when they aren't cached.
+	Source Source
+
+	// Dir is the directory documents are cached in.
+	Dir string
+
+	// MaxSize is the maximum size of the cache in bytes. The least
+	// recently used documents are evicted once it's exceeded. Zero
+	// disables the limit.
+	MaxSize int64
+
+	// BlobDir, if set, is the [Client.BlobDir] of the source. It counts
+	// toward MaxSize, the files of other documents are evicted from it
+	// like cached documents.
+	BlobDir string
+}
+
+// DefaultCacheDir returns the default directory for cached files.
+func DefaultCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "remarkabledayone"), nil
+}
+
+// NewCache creates a new [Cache] in front of the provided source. If dir
+// is empty, a directory in [DefaultCacheDir] is used.
+func NewCache(log *slog.Logger, src Source, dir string, maxSize int64) (*Cache, error) {
+	if dir == "" {
+		cacheDir, err := DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(cacheDir, "documents")
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &Cache{log: log, Source: src, Dir: dir, MaxSize: maxSize}, nil
+}
+
+// ListDocuments implements [Source].
+func (c *Cache) ListDocuments(ctx context.Context) ([]DocumentInfo, error) {
+	return c.Source.ListDocuments(ctx)
+}
+
+// DownloadDocument implements [Source]. Documents are cached by their
+// ID, version and modification time. Documents without a version or
+// modification time are never cached, since changes can't be detected.
+func (c *Cache) DownloadDocument(ctx context.Context, info *DocumentInfo) (*Document, error) {
+	if info.Version == 0 && info.Modified.IsZero() {
+		return c.Source.DownloadDocument(ctx, info)
+	}
+
+	key := cacheKey(info)
+	entry := filepath.Join(c.Dir, key)
+	if _, err := os.Stat(entry); err == nil {
+		doc, err := (&Local{log: c.log, Dir: entry}).DownloadDocument(ctx, info)
+		if err == nil {
+			c.log.Info("using cached document", "name", info.Name, "version", info.Version)
+
+			// Used for eviction.
+			now := time.Now()
+			if err := os.Chtimes(entry, now, now); err != nil {
+				c.log.With("error", err).Warn("failed to update cache entry")
+			}
+			return doc, nil
+		}
+
+		c.log.With("error", err, "path", entry).Warn("failed to read cached document, downloading it again")
+		os.RemoveAll(entry) //nolint:errcheck // Why: Best effort.
+	}
+
+	doc, err := c.Source.DownloadDocument(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store(ctx, entry, doc); err != nil {
+		c.log.With("error", err).Warn("failed to cache document")
+	} else if err := c.evict(info.ID, key); err != nil {
+		c.log.With("error", err).Warn("failed to evict cached documents")
+	}
+
+	return doc, nil
+}
+
+// cacheKey returns the name of the cache entry for a document.
+func cacheKey(info *DocumentInfo) string {
+	return fmt.Sprintf("%s-%d-%d", filepath.Base(info.ID), info.Version, info.Modified.Unix())
+}
+
+// store copies the document into the cache entry at the provided path.
+// The entry is written to a temporary directory first so that partial
+// entries are never read.
+func (c *Cache) store(ctx context.Context, entry string, doc *Document) error {
+	tmp, err := os.MkdirTemp(c.Dir, filepath.Base(entry)+"-*"+tmpSuffix)
+	if err != nil {
+		return err
+	}
+
+	if err := copyDocument(ctx, doc.tmpDir, tmp, doc.Zip.ID); err != nil {
+		os.RemoveAll(tmp) //nolint:errcheck // Why: Best effort.
+		return err
+	}
+
+	if err := os.Rename(tmp, entry); err != nil {
+		os.RemoveAll(tmp) //nolint:errcheck // Why: Best effort.
+		return err
+	}
+
+	return nil
+}
+
+// cacheEntry is an entry in the cache, used for eviction.
+type cacheEntry struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// evict removes older versions of the document with the provided ID,
+// then the least recently used entries, including the files of other
+// documents in BlobDir, until the cache fits in MaxSize. The entry with
+// the provided key and the files of the document are never evicted.
+func (c *Cache) evict(id, key string) error {
+	files, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]cacheEntry, 0, len(files))
+	var total int64
+	for _, f := range files {
+		if !f.IsDir() || f.Name() == key {
+			continue
+		}
+
+		path := filepath.Join(c.Dir, f.Name())
+		if strings.HasPrefix(f.Name(), id+"-") && !strings.HasSuffix(f.Name(), tmpSuffix) {
+			c.log.Debug("removing outdated cached document", "entry", f.Name())
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		e, err := newCacheEntry(path, f)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, *e)
+		total += e.size
+	}
+
+	if c.MaxSize <= 0 {
+		return nil
+	}
+
+	keep := []string{filepath.Join(c.Dir, key)}
+	if c.BlobDir != "" {
+		blobs, err := os.ReadDir(c.BlobDir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		for _, f := range blobs {
+			path := filepath.Join(c.BlobDir, f.Name())
+			if !f.IsDir() {
+				continue
+			}
+			if f.Name() == filepath.Base(id) {
+				keep = append(keep, path)
+				continue
+			}
+
+			e, err := newCacheEntry(path, f)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, *e)
+			total += e.size
+		}
+	}
+	for _, path := range keep {
+		size, err := dirSize(path)
+		if err != nil {
+			return err
+		}
+		total += size
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].used.Before(entries[j].used)
+	})
+	for _, e := range entries {
+		if total <= c.MaxSize {
+			break
+		}
+
+		c.log.Debug("evicting cached files", "path", e.path, "size", e.size)
+		if err := os.RemoveAll(e.path); err != nil {
+			return err
+		}
+		total -= e.size
+	}
+
+	return nil
+}
+
+// newCacheEntry creates a [cacheEntry] for the directory at path.
+func newCacheEntry(path string, f fs.DirEntry) (*cacheEntry, error) {
+	info, err := f.Info()
+	if err != nil {
+		return nil, err
+	}
+	size, err := dirSize(path)
+	if err != nil {
+		return nil, err
+	}
+	return &cacheEntry{path, size, info.ModTime()}, nil
+}
+
+// dirSize returns the total size of the files in a directory.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+
+	return size, err
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCacheDir creates a directory containing a single file of the
+// provided size, last used at the provided time.
+func writeCacheDir(t *testing.T, path string, size int, used time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "file"), make([]byte, size), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, used, used); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheEvict(t *testing.T) {
+	now := time.Now()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// The document downloaded through the cache.
+	srcDir := t.TempDir()
+	for name, contents := range map[string][]byte{
+		"doc.metadata": readGolden(t, "document.metadata"),
+		"doc.content":  readGolden(t, "v1.content"),
+		"doc/page.rm":  make([]byte, 1000),
+	} {
+		path := filepath.Join(srcDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, contents, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	docSize, err := dirSize(srcDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir, blobDir := t.TempDir(), t.TempDir()
+	writeCacheDir(t, filepath.Join(cacheDir, "doc-1-100"), 1000, now)
+	writeCacheDir(t, filepath.Join(cacheDir, "old-1-1"), 1000, now.Add(-3*time.Hour))
+	writeCacheDir(t, filepath.Join(cacheDir, "mid-1-1"), 1000, now.Add(-2*time.Hour))
+	writeCacheDir(t, filepath.Join(cacheDir, "new-1-1"), 1000, now.Add(-time.Hour))
+	writeCacheDir(t, filepath.Join(blobDir, "doc"), 1000, now.Add(-5*time.Hour))
+	writeCacheDir(t, filepath.Join(blobDir, "other"), 1000, now.Add(-4*time.Hour))
+
+	// Fits the current document, its files and two other entries.
+	c := &Cache{
+		log:     log,
+		Source:  &Local{log: log, Dir: srcDir},
+		Dir:     cacheDir,
+		MaxSize: docSize + 3000 + 500,
+		BlobDir: blobDir,
+	}
+	info := &DocumentInfo{ID: "doc", Name: "Journal", Version: 2, Modified: time.Unix(200, 0)}
+	doc, err := c.DownloadDocument(context.Background(), info)
+	if err != nil {
+		t.Fatalf("DownloadDocument() error = %v", err)
+	}
+	doc.Close() //nolint:errcheck // Why: Best effort.
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(cacheDir, cacheKey(info)), true},
+		{filepath.Join(cacheDir, "doc-1-100"), false},
+		{filepath.Join(cacheDir, "old-1-1"), false},
+		{filepath.Join(cacheDir, "mid-1-1"), true},
+		{filepath.Join(cacheDir, "new-1-1"), true},
+		{filepath.Join(blobDir, "doc"), true},
+		{filepath.Join(blobDir, "other"), false},
+	}
+	for _, tt := range tests {
+		_, err := os.Stat(tt.path)
+		if got := err == nil; got != tt.want {
+			t.Errorf("%s exists = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
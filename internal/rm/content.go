@@ -0,0 +1,443 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Register is a last-writer-wins value as used throughout the ".content"
+// file. Timestamp is a CRDT timestamp in the "part1:part2" form.
+type Register[T any] struct {
+	Timestamp string `json:"timestamp"`
+	Value     T      `json:"value"`
+}
+
+// Content is the contents of the "<id>.content" file of a document.
+//
+// Decoding and encoding a file round-trips: unknown fields are
+// preserved and known fields keep their presence.
+type Content struct {
+	// FileType is the type of the document: "notebook", "pdf" or "epub".
+	FileType string `json:"fileType"`
+
+	// FormatVersion is the version of this file. Version 1 lists pages
+	// in Pages, version 2 in CPages.
+	FormatVersion int `json:"formatVersion,omitempty"`
+
+	// Orientation is either "portrait" or "landscape".
+	Orientation string `json:"orientation,omitempty"`
+
+	// PageCount is the number of pages in the document.
+	PageCount int `json:"pageCount"`
+
+	// Pages is the ordered list of page IDs (version 1).
+	Pages []string `json:"pages,omitempty"`
+
+	// CPages is the CRDT list of pages (version 2).
+	CPages *CPages `json:"cPages,omitempty"`
+
+	// CoverPageNumber is the page used as the cover, -1 for the last
+	// opened page.
+	CoverPageNumber int `json:"coverPageNumber"`
+
+	// Tags are the tags on the document.
+	Tags []Tag `json:"tags"`
+
+	// PageTags are the tags on individual pages.
+	PageTags []PageTag `json:"pageTags,omitempty"`
+
+	// DocumentMetadata is metadata about the document, e.g., the authors
+	// and title of an EPUB.
+	DocumentMetadata map[string]json.RawMessage `json:"documentMetadata,omitempty"`
+
+	// ExtraMetadata contains the last used tool and pen settings.
+	ExtraMetadata ExtraMetadata `json:"extraMetadata,omitempty"`
+
+	// Text settings, only used for EPUBs.
+	FontName      string  `json:"fontName,omitempty"`
+	LineHeight    int     `json:"lineHeight"`
+	Margins       int     `json:"margins"`
+	TextAlignment string  `json:"textAlignment,omitempty"`
+	TextScale     float64 `json:"textScale"`
+
+	// Zoom settings.
+	ZoomMode              string  `json:"zoomMode,omitempty"`
+	CustomZoomCenterX     float64 `json:"customZoomCenterX"`
+	CustomZoomCenterY     float64 `json:"customZoomCenterY"`
+	CustomZoomOrientation string  `json:"customZoomOrientation,omitempty"`
+	CustomZoomPageHeight  float64 `json:"customZoomPageHeight"`
+	CustomZoomPageWidth   float64 `json:"customZoomPageWidth"`
+	CustomZoomScale       float64 `json:"customZoomScale"`
+
+	// SizeInBytes is the size of the document, as a string.
+	SizeInBytes string `json:"sizeInBytes,omitempty"`
+
+	extra jsonExtra
+}
+
+// CPages is the CRDT list of pages in a version 2 ".content" file.
+type CPages struct {
+	// Pages is the list of pages. Use [Content.OrderedPages] to get them
+	// in document order.
+	Pages []CPage `json:"pages"`
+
+	// LastOpened is the ID of the last opened page.
+	LastOpened Register[string] `json:"lastOpened"`
+
+	// Original is the page count of the original document (e.g., PDF),
+	// -1 for notebooks.
+	Original Register[int] `json:"original"`
+
+	// UUIDs is the list of devices that have edited the list of pages.
+	UUIDs []CPagesUUID `json:"uuids,omitempty"`
+
+	extra jsonExtra
+}
+
+// CPagesUUID maps a device UUID to the ID used for it in timestamps.
+type CPagesUUID struct {
+	First  string `json:"first"`
+	Second int    `json:"second"`
+}
+
+// CPage is a single page in [CPages].
+type CPage struct {
+	// ID is the ID of the page, matching the "<id>.rm" file.
+	ID string `json:"id"`
+
+	// Idx is the position of the page. Pages are ordered by comparing
+	// this value as a string.
+	Idx Register[string] `json:"idx"`
+
+	// Template is the name of the page template, e.g., "Blank".
+	Template *Register[string] `json:"template,omitempty"`
+
+	// Redir is the page of the original document (e.g., PDF) this page
+	// shows. Not set for inserted pages.
+	Redir *Register[int] `json:"redir,omitempty"`
+
+	// ScrollTime is when the page was last scrolled.
+	ScrollTime *Register[string] `json:"scrollTime,omitempty"`
+
+	// VerticalScroll is the scroll position of the page.
+	VerticalScroll *Register[float64] `json:"verticalScroll,omitempty"`
+
+	// Deleted is set to a non-zero value when the page has been deleted.
+	Deleted *Register[int] `json:"deleted,omitempty"`
+
+	// Modified is when the page was last modified, in milliseconds since
+	// the unix epoch, as a string. The typo is part of the format.
+	Modified string `json:"modifed,omitempty"`
+
+	extra jsonExtra
+}
+
+// IsDeleted returns true if the page has been deleted.
+func (p *CPage) IsDeleted() bool {
+	return p.Deleted != nil && p.Deleted.Value != 0
+}
+
+// ModifiedTime returns [CPage.Modified] as a [time.Time]. If it isn't
+// set, the zero time is returned.
+func (p *CPage) ModifiedTime() time.Time {
+	ms, err := strconv.ParseInt(p.Modified, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// Tag is a tag on a document.
+type Tag struct {
+	Name      string `json:"name"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// PageTag is a tag on a page of a document.
+type PageTag struct {
+	Name      string `json:"name"`
+	PageID    string `json:"pageId"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// ExtraMetadata contains the last used tools and pen settings of a
+// document, e.g., "LastTool": "Finelinerv2" or
+// "LastFinelinerv2Color": "Black". The keys depend on the firmware
+// version, so they're kept as a map.
+type ExtraMetadata map[string]string
+
+// LastTool returns the last used tool, if known.
+func (e ExtraMetadata) LastTool() string {
+	return e["LastTool"]
+}
+
+// PenSettings returns the last used color, size and (for some tools)
+// opacity for the given pen, e.g., "Finelinerv2".
+func (e ExtraMetadata) PenSettings(pen string) (color, size, opacity string) {
+	return e["Last"+pen+"Color"], e["Last"+pen+"Size"], e["Last"+pen+"Opacity"]
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (c *Content) UnmarshalJSON(b []byte) error {
+	type plain Content
+	return c.extra.unmarshal(b, (*plain)(c))
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (c *Content) MarshalJSON() ([]byte, error) {
+	type plain Content
+	return c.extra.marshal((*plain)(c))
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (c *CPages) UnmarshalJSON(b []byte) error {
+	type plain CPages
+	return c.extra.unmarshal(b, (*plain)(c))
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (c *CPages) MarshalJSON() ([]byte, error) {
+	type plain CPages
+	return c.extra.marshal((*plain)(c))
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (p *CPage) UnmarshalJSON(b []byte) error {
+	type plain CPage
+	return p.extra.unmarshal(b, (*plain)(p))
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (p *CPage) MarshalJSON() ([]byte, error) {
+	type plain CPage
+	return p.extra.marshal((*plain)(p))
+}
+
+// ReadContent reads the ".content" file at the provided path.
+func ReadContent(path string) (*Content, error) {
+	//#nosec:G304 // Why: Safe for our usecase.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Content{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ContentPage is a page of a document as listed in its ".content" file.
+type ContentPage struct {
+	// ID is the ID of the page.
+	ID string
+
+	// Template is the name of the page template, if known.
+	Template string
+
+	// Modified is when the page was last modified, if known.
+	Modified time.Time
+}
+
+// OrderedPages returns the pages of the document, in order. Deleted
+// pages are omitted.
+func (c *Content) OrderedPages() []ContentPage {
+	if c.CPages == nil || len(c.CPages.Pages) == 0 {
+		pages := make([]ContentPage, 0, len(c.Pages))
+		for _, id := range c.Pages {
+			pages = append(pages, ContentPage{ID: id})
+		}
+		return pages
+	}
+
+	cps := make([]CPage, len(c.CPages.Pages))
+	copy(cps, c.CPages.Pages)
+	sort.SliceStable(cps, func(i, j int) bool { return cps[i].Idx.Value < cps[j].Idx.Value })
+
+	pages := make([]ContentPage, 0, len(cps))
+	for i := range cps {
+		cp := &cps[i]
+		if cp.IsDeleted() {
+			continue
+		}
+
+		p := ContentPage{ID: cp.ID, Modified: cp.ModifiedTime()}
+		if cp.Template != nil {
+			p.Template = cp.Template.Value
+		}
+		pages = append(pages, p)
+	}
+
+	return pages
+}
+
+// PageData is the contents of the "<id>.pagedata" file of a document: the
+// template name of each page, one per line. It is only kept up to date
+// by older firmware, newer firmware uses [CPage.Template].
+type PageData []string
+
+// ReadPageData reads the ".pagedata" file at the provided path.
+func ReadPageData(path string) (PageData, error) {
+	//#nosec:G304 // Why: Safe for our usecase.
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Why: Best effort.
+
+	return ParsePageData(f)
+}
+
+// ParsePageData parses the contents of a ".pagedata" file.
+func ParsePageData(r io.Reader) (PageData, error) {
+	var pd PageData
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		pd = append(pd, strings.TrimSuffix(s.Text(), "\r"))
+	}
+	return pd, s.Err()
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (pd PageData) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	for _, t := range pd {
+		buf.WriteString(t)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (pd *PageData) UnmarshalText(b []byte) error {
+	parsed, err := ParsePageData(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	*pd = parsed
+	return nil
+}
+
+// jsonExtra keeps track of the JSON an object was decoded from, so that
+// encoding it again produces the same set of keys.
+type jsonExtra struct {
+	// unknown contains the keys not known by the model.
+	unknown map[string]json.RawMessage
+
+	// keys is the set of keys that were present when decoded.
+	keys map[string]struct{}
+}
+
+// unmarshal decodes b into v, which must be a pointer to a struct.
+func (e *jsonExtra) unmarshal(b []byte, v any) error {
+	if err := json.Unmarshal(b, v); err != nil {
+		return err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+
+	e.keys = make(map[string]struct{}, len(all))
+	for k := range all {
+		e.keys[k] = struct{}{}
+	}
+	for k := range jsonFields(v) {
+		delete(all, k)
+	}
+	e.unknown = all
+
+	return nil
+}
+
+// marshal encodes v, which must be a pointer to a struct. If v was
+// decoded, the known fields that were present at the time are always
+// included (even if empty). The rest are left out unless they've been
+// set since.
+func (e *jsonExtra) marshal(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || (len(e.keys) == 0 && len(e.unknown) == 0) {
+		return b, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	for k, i := range jsonFields(v) {
+		_, present := e.keys[k]
+		if _, ok := all[k]; ok {
+			// Don't add keys that weren't present when decoded, unless
+			// they've been set since.
+			if !present && e.keys != nil && rv.Field(i).IsZero() {
+				delete(all, k)
+			}
+			continue
+		}
+		if !present {
+			continue
+		}
+		fb, err := json.Marshal(rv.Field(i).Addr().Interface())
+		if err != nil {
+			return nil, err
+		}
+		all[k] = fb
+	}
+	for k, v := range e.unknown {
+		all[k] = v
+	}
+
+	return json.Marshal(all)
+}
+
+// jsonFields returns the JSON keys of the fields of the struct pointed
+// to by v, mapped to their field index.
+func jsonFields(v any) map[string]int {
+	t := reflect.TypeOf(v).Elem()
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
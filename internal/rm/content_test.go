@@ -0,0 +1,182 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// assertSameJSON fails if got and want don't decode to the same value.
+// Key order and whitespace are ignored.
+func assertSameJSON(t *testing.T, got, want []byte) {
+	t.Helper()
+
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("failed to decode encoded file: %v", err)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatalf("failed to decode golden file: %v", err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("encoded file doesn't match golden file\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+// readGolden reads the provided file from the testdata directory.
+func readGolden(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestContentRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		pages []string
+	}{
+		{
+			name: "version 1",
+			file: "v1.content",
+			pages: []string{
+				"0b3b4e3c-0c2c-4f5e-9a63-0f2b8e6c1a01",
+				"5d1f8a2e-7c4b-4b7e-8f0a-3e9c2d6b4a02",
+				"9e7a6c5b-2d3f-4a1e-b8c9-7f0e1d2c3b03",
+			},
+		},
+		{
+			name: "version 2",
+			file: "v2.content",
+			pages: []string{
+				"0b3b4e3c-0c2c-4f5e-9a63-0f2b8e6c1a01",
+				"5d1f8a2e-7c4b-4b7e-8f0a-3e9c2d6b4a02",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := readGolden(t, tt.file)
+
+			c, err := ReadContent(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatalf("ReadContent() error = %v", err)
+			}
+
+			var pages []string
+			for _, p := range c.OrderedPages() {
+				pages = append(pages, p.ID)
+			}
+			if !reflect.DeepEqual(pages, tt.pages) {
+				t.Errorf("OrderedPages() = %v, want %v", pages, tt.pages)
+			}
+
+			got, err := json.Marshal(c)
+			if err != nil {
+				t.Fatalf("failed to encode content: %v", err)
+			}
+			assertSameJSON(t, got, want)
+		})
+	}
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	want := readGolden(t, "document.metadata")
+
+	var m Metadata
+	if err := json.Unmarshal(want, &m); err != nil {
+		t.Fatalf("failed to decode metadata: %v", err)
+	}
+	if m.VisibleName != "Journal" || m.LastOpenedPage != 1 {
+		t.Errorf("decoded metadata = %+v", m)
+	}
+
+	got, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatalf("failed to encode metadata: %v", err)
+	}
+	assertSameJSON(t, got, want)
+}
+
+func TestPageDataRoundTrip(t *testing.T) {
+	want := readGolden(t, "document.pagedata")
+
+	pd, err := ReadPageData(filepath.Join("testdata", "document.pagedata"))
+	if err != nil {
+		t.Fatalf("ReadPageData() error = %v", err)
+	}
+	if wantPD := (PageData{"Blank", "Lined", "P Grid small"}); !reflect.DeepEqual(pd, wantPD) {
+		t.Errorf("ReadPageData() = %q, want %q", pd, wantPD)
+	}
+
+	got, err := pd.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("MarshalText() = %q, want %q", got, want)
+	}
+}
+
+func TestRoundTripSetField(t *testing.T) {
+	c, err := ReadContent(filepath.Join("testdata", "v1.content"))
+	if err != nil {
+		t.Fatalf("ReadContent() error = %v", err)
+	}
+	c.Tags = []Tag{{Name: "daily", Timestamp: 1760000000000}}
+
+	var m Metadata
+	if err := json.Unmarshal(readGolden(t, "document.metadata"), &m); err != nil {
+		t.Fatalf("failed to decode metadata: %v", err)
+	}
+	m.Deleted = true
+
+	tests := []struct {
+		name string
+		v    any
+		key  string
+		want string
+	}{
+		{"content", c, "tags", `[{"name":"daily","timestamp":1760000000000}]`},
+		{"metadata", &m, "deleted", `true`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatalf("failed to encode: %v", err)
+			}
+			var got map[string]json.RawMessage
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if string(got[tt.key]) != tt.want {
+				t.Errorf("%s = %s, want %s", tt.key, got[tt.key], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"math"
+)
+
+// pageArea is the area of a page in screen units (1404x1872).
+const pageArea = 1404 * 1872
+
+// stroke is a single pen stroke on a page.
+type stroke struct {
+	// parent is the scene tree node containing the stroke.
+	parent crdtID
+
+	// area is the approximate area covered by the stroke in screen units.
+	area float64
+}
+
+// point is a single point of a stroke.
+type point struct {
+	x, y  float64
+	width float64
+}
+
+// readLineItem reads a SceneLineItem block. If the stroke has been
+// deleted, nil is returned.
+func readLineItem(r *reader, version uint8) (*stroke, error) {
+	it, err := readSequenceItem(r, 0x03)
+	if err != nil {
+		return nil, err
+	}
+	if it.value == nil {
+		return nil, nil //nolint:nilnil // Why: Deleted items have no value.
+	}
+	v := it.value
+
+	// tool, color, thickness scale and starting length, none of which we
+	// need.
+	if _, err := v.int(1); err != nil {
+		return nil, err
+	}
+	if _, err := v.int(2); err != nil {
+		return nil, err
+	}
+	if _, err := v.double(3); err != nil {
+		return nil, err
+	}
+	if _, err := v.int(4); err != nil {
+		return nil, err
+	}
+
+	pb, err := v.subblock(5)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev *point
+	st := &stroke{parent: it.parentID}
+	for pb.remaining() > 0 {
+		p, err := readPoint(pb, version)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil {
+			st.area += math.Hypot(p.x-prev.x, p.y-prev.y) * (p.width + prev.width) / 2
+		}
+		prev = p
+	}
+
+	return st, nil
+}
+
+// readPoint reads a single point of a stroke. Version 1 stores every
+// value as a float32, later versions use a packed format.
+func readPoint(r *reader, version uint8) (*point, error) {
+	x, err := r.float32()
+	if err != nil {
+		return nil, err
+	}
+	y, err := r.float32()
+	if err != nil {
+		return nil, err
+	}
+	p := &point{x: float64(x), y: float64(y)}
+
+	if version == 1 {
+		// speed, direction, width, pressure
+		vals := make([]float32, 4)
+		for i := range vals {
+			if vals[i], err = r.float32(); err != nil {
+				return nil, err
+			}
+		}
+		p.width = float64(vals[2])
+		return p, nil
+	}
+
+	if _, err := r.uint16(); err != nil { // speed
+		return nil, err
+	}
+	width, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.bytes(2); err != nil { // direction, pressure
+		return nil, err
+	}
+	p.width = float64(width) / 4
+
+	return p, nil
+}
+
+// IsBlank returns true if the scene has no typed text or highlights and
+// its ink coverage is at or below the provided threshold. A threshold of
+// zero only matches pages without any strokes.
+func (s *Scene) IsBlank(threshold float64) bool {
+	if s.Markdown() != "" {
+		return false
+	}
+	if s.Strokes == 0 {
+		return true
+	}
+	return s.InkCoverage <= threshold && threshold > 0
+}
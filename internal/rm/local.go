@@ -0,0 +1,249 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// trashParent is the parent of documents in the trash.
+const trashParent = "trash"
+
+// Local reads documents from a local copy of the device's document
+// tree, e.g., a backup of "/home/root/.local/share/remarkable/xochitl"
+// made over SSH or USB. Create with [NewLocal].
+type Local struct {
+	log *slog.Logger
+
+	// Dir is the directory containing the document tree.
+	Dir string
+}
+
+// NewLocal creates a new [Local] reading from the provided directory.
+func NewLocal(log *slog.Logger, dir string) (*Local, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("local directory is required")
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	return &Local{log: log, Dir: dir}, nil
+}
+
+// ListDocuments implements [Source].
+func (l *Local) ListDocuments(ctx context.Context) ([]DocumentInfo, error) {
+	files, err := filepath.Glob(filepath.Join(l.Dir, "*.metadata"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*Metadata, len(files))
+	for _, p := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		//#nosec:G304 // Why: Safe for our usecase.
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+
+		m := &Metadata{}
+		if err := json.Unmarshal(b, m); err != nil {
+			l.log.With("error", err, "path", p).Warn("failed to read metadata, skipping")
+			continue
+		}
+		entries[strings.TrimSuffix(filepath.Base(p), ".metadata")] = m
+	}
+
+	docs := make([]DocumentInfo, 0)
+	for id, m := range entries {
+		if m.Type != "DocumentType" || m.Deleted {
+			continue
+		}
+
+		p, ok := l.path(entries, id)
+		if !ok {
+			continue
+		}
+
+		var modified time.Time
+		if ms, err := strconv.ParseInt(m.LastModified, 10, 64); err == nil {
+			modified = time.UnixMilli(ms)
+		}
+		docs = append(docs, DocumentInfo{
+			ID:       id,
+			Name:     m.VisibleName,
+			Path:     p,
+			Version:  m.Version,
+			Modified: modified,
+		})
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Path < docs[j].Path
+	})
+
+	return docs, nil
+}
+
+// path returns the path of the entry with the provided ID by walking
+// its parents. Returns false if the entry is in the trash.
+func (l *Local) path(entries map[string]*Metadata, id string) (string, bool) {
+	var parts []string
+	seen := make(map[string]struct{})
+	for id != "" {
+		if id == trashParent {
+			return "", false
+		}
+		if _, ok := seen[id]; ok {
+			// Cyclic parents, treat what we have as the full path.
+			break
+		}
+		seen[id] = struct{}{}
+
+		m, ok := entries[id]
+		if !ok {
+			// Missing parents are treated as the root, like the cloud does.
+			break
+		}
+		if m.Deleted {
+			return "", false
+		}
+		parts = append([]string{m.VisibleName}, parts...)
+		id = m.Parent
+	}
+
+	return path.Join(parts...), true
+}
+
+// DownloadDocument implements [Source]. The document is copied into a
+// temporary directory, keeping rendered pages out of the document tree.
+func (l *Local) DownloadDocument(ctx context.Context, info *DocumentInfo) (*Document, error) {
+	tmpDir, err := os.MkdirTemp("", "remarkabledayone-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	doc, err := l.readDocument(ctx, tmpDir, info.ID)
+	if err != nil {
+		os.RemoveAll(tmpDir) //nolint:errcheck // Why: Best effort.
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// readDocument copies the files of the document into tmpDir and reads
+// them.
+func (l *Local) readDocument(ctx context.Context, tmpDir, id string) (*Document, error) {
+	if err := copyDocument(ctx, l.Dir, tmpDir, id); err != nil {
+		return nil, err
+	}
+
+	z, err := newZipFromDir(tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	l.log.Info("read local document", "name", z.Metadata.VisibleName, "path", filepath.Join(l.Dir, id))
+
+	return &Document{Path: tmpDir, Zip: z, tmpDir: tmpDir}, nil
+}
+
+// copyDocument copies the files needed to read the document with the
+// provided ID from srcDir into destDir.
+func copyDocument(ctx context.Context, srcDir, destDir, id string) error {
+	for _, ext := range []string{".metadata", ".content", ".pagedata"} {
+		err := copyFile(filepath.Join(srcDir, id+ext), filepath.Join(destDir, id+ext))
+		if err != nil && (ext == ".metadata" || !errors.Is(err, os.ErrNotExist)) {
+			return err
+		}
+	}
+
+	pagesDir := filepath.Join(srcDir, id)
+	files, err := os.ReadDir(pagesDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(destDir, id), 0o750); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".rm") {
+			continue
+		}
+		if err := copyFile(filepath.Join(pagesDir, f.Name()), filepath.Join(destDir, id, f.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies src to dest, keeping the modification time so that
+// pages without timestamps in the ".content" file are still dated.
+func copyFile(src, dest string) error {
+	//#nosec:G304 // Why: Safe for our usecase.
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() //nolint:errcheck // Why: Best effort.
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	//#nosec:G304 // Why: Safe for our usecase.
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close() //nolint:errcheck // Why: Best effort.
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dest, info.ModTime(), info.ModTime())
+}
@@ -18,6 +18,7 @@
 package rm
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,8 +26,9 @@ import (
 	"github.com/jaredallard/cmdexec"
 )
 
-// RenderRmToPng renders a remarkable document to a PNG file.
-func RenderRmToPng(src, dest string) error {
+// RenderRmToPng renders a remarkable document to a PNG file. The
+// external commands used are killed if the context is cancelled.
+func RenderRmToPng(ctx context.Context, src, dest string) error {
 	tmpDir, err := os.MkdirTemp("", "rm-render-")
 	if err != nil {
 		return err
@@ -41,8 +43,10 @@ func RenderRmToPng(src, dest string) error {
 		{"convert", "-verbose", "-density", "150", "-trim", pdfFile, "-quality", "100", "-flatten", "-sharpen", "0x1.0", outputFile},
 	}
 	for _, cmd := range cmds {
-		cmd := cmdexec.Command(cmd[0], cmd[1:]...)
-		cmd.SetStdout(os.Stdout)
+		cmd := cmdexec.CommandContext(ctx, cmd[0], cmd[1:]...)
+		// Stdout is reserved for the sync summary, so the output of the
+		// tools goes to stderr.
+		cmd.SetStdout(os.Stderr)
 		cmd.SetStderr(os.Stderr)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to run command %v: %w", cmd, err)
@@ -20,17 +20,20 @@
 package rm
 
 import (
-	"archive/zip"
+	"context"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
-	"github.com/charmbracelet/log"
+	"github.com/jaredallard/remarkabledayone/internal/metrics"
 	"github.com/juruen/rmapi/api"
+	"github.com/juruen/rmapi/api/sync15"
+	"github.com/juruen/rmapi/filetree"
 	"github.com/juruen/rmapi/model"
 	"github.com/juruen/rmapi/transport"
 )
@@ -38,31 +41,51 @@ import (
 // Client is a client for interacting with remarkable documents
 // (journals).
 type Client struct {
-	log  *slog.Logger
-	rm   api.ApiCtx
-	user *api.UserInfo
+	log   *slog.Logger
+	rm    api.ApiCtx
+	blobs *sync15.BlobStorage
+	user  *api.UserInfo
+
+	// BlobDir, if set, is where the files of downloaded documents are
+	// kept so that only new or changed files are downloaded next time.
+	BlobDir string
+
+	// Unchanged, if set, reports whether the page with the provided ID
+	// is known with the provided hash and doesn't need to be downloaded,
+	// e.g., because it has already been synced. Such pages are only
+	// copied from BlobDir, if they're there, otherwise they're left
+	// without contents.
+	Unchanged func(pageID, hash string) bool
+
+	// refresh downloads every file again, see [Client.Refreshing].
+	refresh bool
+}
+
+// Refreshing returns a copy of the client that downloads every file of a
+// document again, replacing the ones in BlobDir, rather than reusing or
+// skipping any.
+func (c *Client) Refreshing() *Client {
+	rc := *c
+	rc.refresh = true
+	return &rc
 }
 
 // Document represents an archived remarkable journal.
 type Document struct {
 	Path string
 	Zip  *Zip
-}
 
-// remarkableAuth attempts to authenticate with remarkable's API.
-func remarkableAuth(_ *slog.Logger) (api.ApiCtx, *api.UserInfo, error) {
-	for range 3 {
-		ctx, userInf, err := apiCtxForTransport(api.AuthHttpCtx(true, false))
-		if err != nil {
-			log.With("error", err).Error("failed to authenticate")
-			continue
-		}
+	// tmpDir is the temporary directory containing the document, removed
+	// by [Document.Close].
+	tmpDir string
+}
 
-		return ctx, userInf, nil
+// Close removes the temporary files of the document.
+func (d *Document) Close() error {
+	if d.tmpDir == "" {
+		return nil
 	}
-
-	// If we've reached this point, we've failed to authenticate.
-	return nil, nil, fmt.Errorf("failed to authenticate")
+	return os.RemoveAll(d.tmpDir)
 }
 
 // apiCtxForTransport creates an API context for the given transport.
@@ -80,34 +103,65 @@ func apiCtxForTransport(tctx *transport.HttpClientCtx) (api.ApiCtx, *api.UserInf
 	return apiCtx, userInfo, nil
 }
 
-// New creates a new Client for interacting with the Remarkable API.
+// New creates a new Client for interacting with the Remarkable API,
+// authenticating with the provided [Auth].
 //
 //nolint:gocritic // Why: Acceptable shadow.
-func New(log *slog.Logger) (*Client, error) {
-	ctx, userInfo, err := remarkableAuth(log)
+func New(log *slog.Logger, auth *Auth) (*Client, error) {
+	tctx, err := auth.httpCtx()
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{log, ctx, userInfo}, nil
+	// Creating the API context fetches the document tree.
+	start := time.Now()
+	ctx, userInfo, err := apiCtxForTransport(tctx)
+	metrics.ObserveCloudRequest("tree", start)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{log: log, rm: ctx, blobs: sync15.NewBlobStorage(tctx), user: userInfo}, nil
 }
 
-// ListDocuments returns a list of all documents on the Remarkable.
-func (c *Client) ListDocuments() []*model.Node {
-	mDocs := c.rm.Filetree().Root().Children
+// ListDocuments implements [Source].
+func (c *Client) ListDocuments(ctx context.Context) ([]DocumentInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	// Convert to []*model.Node
-	docs := make([]*model.Node, 0, len(mDocs))
-	for i := range mDocs {
-		docs = append(docs, mDocs[i])
+	docs := make([]DocumentInfo, 0)
+	var walk func(n *model.Node, dir string)
+	walk = func(n *model.Node, dir string) {
+		for _, child := range n.Children {
+			if child.Id() == filetree.TrashID {
+				continue
+			}
+
+			p := path.Join(dir, child.Name())
+			if child.IsDirectory() {
+				walk(child, p)
+				continue
+			}
+
+			// Unparsable timestamps are left as zero, they're informational.
+			modified, _ := child.LastModified() //nolint:errcheck // Why: See above.
+			docs = append(docs, DocumentInfo{
+				ID:       child.Id(),
+				Name:     child.Name(),
+				Path:     p,
+				Version:  child.Version(),
+				Modified: modified,
+			})
+		}
 	}
+	walk(c.rm.Filetree().Root(), "")
 
-	// Sort by name
 	sort.Slice(docs, func(i, j int) bool {
-		return docs[i].Name() < docs[j].Name()
+		return docs[i].Path < docs[j].Path
 	})
 
-	return docs
+	return docs, nil
 }
 
 // sanitizeArchivePath to mitigate "G305".
@@ -120,88 +174,25 @@ func sanitizeArchivePath(d, t string) (v string, err error) {
 	return "", fmt.Errorf("%s: %s", "content filepath is tainted", t)
 }
 
-func (c *Client) zipFromArchive(tmpDir, path string) (*Zip, error) {
-	//#nosec:G304 // Why: Safe for our usecase.
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close() //nolint:errcheck // Why: Best effort.
-
-	fInf, err := f.Stat()
-	if err != nil {
-		return nil, err
-	}
-
-	zr, err := zip.NewReader(f, fInf.Size())
-	if err != nil {
-		return nil, err
-	}
-
-	for _, f := range zr.File {
-		if err := func() error {
-			zf, err := f.Open()
-			if err != nil {
-				return err
-			}
-			defer zf.Close() //nolint:errcheck // Why: Best effort.
-
-			// Skip directories.
-			if f.FileInfo().IsDir() {
-				return nil
-			}
-
-			// Create the file in the temporary directory.
-			outPath, err := sanitizeArchivePath(tmpDir, f.Name)
-			if err != nil {
-				return err
-			}
-
-			// Ensure the directory exists.
-			if err := os.MkdirAll(filepath.Dir(outPath), 0o750); err != nil {
-				return err
-			}
-
-			//#nosec:G304 // Why: Safe for our usecase.
-			out, err := os.Create(outPath)
-			if err != nil {
-				return err
-			}
-			defer out.Close() //nolint:errcheck // Why: Best effort.
-
-			// Copy the file.
-			//#nosec:G110 // Why: This is acceptable for our use case.
-			if _, err := io.Copy(out, zf); err != nil {
-				return err
-			}
-
-			return nil
-		}(); err != nil {
-			return nil, err
-		}
-	}
-
-	return newZipFromDir(tmpDir)
-}
-
-// DownloadDocument downloads a document from Remarkable and returns it.
-func (c *Client) DownloadDocument(doc *model.Document) (*Document, error) {
-	tmpDir, err := os.MkdirTemp("", "remarkabledayone")
+// DownloadDocument implements [Source]. Only the files needed to read
+// the document are downloaded, see [Client.BlobDir].
+func (c *Client) DownloadDocument(ctx context.Context, info *DocumentInfo) (*Document, error) {
+	tmpDir, err := os.MkdirTemp("", "remarkabledayone-*")
 	if err != nil {
-		log.With("error", err).Error("failed to create temporary directory")
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
 	}
-	tmpFile := filepath.Join(tmpDir, strings.TrimSuffix(doc.Name, ".zip")+".zip")
 
-	if err := c.rm.FetchDocument(doc.ID, tmpFile); err != nil {
+	if err := c.fetchDocumentBlobs(ctx, info.ID, tmpDir); err != nil {
+		os.RemoveAll(tmpDir) //nolint:errcheck // Why: Best effort.
 		return nil, err
 	}
 
-	c.log.Info("downloaded document", "name", doc.Name, "path", tmpFile)
-	z, err := c.zipFromArchive(tmpDir, tmpFile)
+	c.log.Info("downloaded document", "name", info.Name, "path", tmpDir)
+	z, err := newZipFromDir(tmpDir)
 	if err != nil {
+		os.RemoveAll(tmpDir) //nolint:errcheck // Why: Best effort.
 		return nil, err
 	}
 
-	return &Document{tmpFile, z}, nil
+	return &Document{tmpDir, z, tmpDir}, nil
 }
@@ -0,0 +1,512 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// sceneHeader is the header at the start of every v6 ".rm" file. It is
+// padded with spaces to [sceneHeaderLen] bytes.
+const (
+	sceneHeader    = "reMarkable .lines file, version=6"
+	sceneHeaderLen = 43
+)
+
+// Block types found in a v6 ".rm" file. Only the blocks we currently
+// care about are parsed, the rest are skipped.
+const (
+	blockSceneTree      = 0x01
+	blockTreeNode       = 0x02
+	blockSceneGlyphItem = 0x03
+	blockSceneLineItem  = 0x05
+	blockRootText       = 0x07
+)
+
+// Tag types used by the tagged values inside of a block.
+const (
+	tagByte1   = 0x1
+	tagByte4   = 0x4
+	tagByte8   = 0x8
+	tagLength4 = 0xC
+	tagID      = 0xF
+)
+
+// crdtID is the identifier used for items in the CRDT structures of a
+// v6 ".rm" file.
+type crdtID struct {
+	Part1 uint8
+	Part2 uint64
+}
+
+// less returns true if c sorts before o.
+func (c crdtID) less(o crdtID) bool {
+	if c.Part1 != o.Part1 {
+		return c.Part1 < o.Part1
+	}
+	return c.Part2 < o.Part2
+}
+
+// String returns the ID in the "part1:part2" form.
+func (c crdtID) String() string {
+	return fmt.Sprintf("%d:%d", c.Part1, c.Part2)
+}
+
+var (
+	// endMarker is the [crdtID] used to mark the start or end of a
+	// sequence.
+	endMarker = crdtID{}
+
+	// rootNode is the [crdtID] of the root group of a page. Layers are
+	// the groups directly underneath it.
+	rootNode = crdtID{0, 1}
+)
+
+// Scene is the parsed contents of a v6 ".rm" page file.
+type Scene struct {
+	// Text is the typed text on the page, if any.
+	Text *Text
+
+	// Highlights is a list of highlighted ranges of text on the page.
+	// These are only present on pages of annotated PDFs/EPUBs.
+	Highlights []Highlight
+
+	// Layers is the list of layers on the page, in order.
+	Layers []Layer
+
+	// Strokes is the number of pen strokes on the page.
+	Strokes int
+
+	// InkCoverage is an estimate of the fraction of the page covered by
+	// strokes, between 0 and 1.
+	InkCoverage float64
+
+	// header is the raw header of the file.
+	header []byte
+
+	// blocks is the list of raw blocks in the file, used for writing
+	// filtered copies of it.
+	blocks []rawBlock
+
+	// parents maps a node in the scene tree to its parent.
+	parents map[crdtID]crdtID
+}
+
+// rawBlock is an unparsed top-level block of a v6 ".rm" file.
+type rawBlock struct {
+	// raw is the block, including its header.
+	raw []byte
+
+	// parent is the parent node of the item contained in the block. Only
+	// set for stroke and highlight blocks.
+	parent *crdtID
+}
+
+// ReadScene reads and parses the v6 ".rm" file at the given path.
+func ReadScene(path string) (*Scene, error) {
+	//#nosec:G304 // Why: Safe for our usecase.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseScene(b)
+}
+
+// parseScene parses the contents of a v6 ".rm" file.
+func parseScene(b []byte) (*Scene, error) {
+	if len(b) < sceneHeaderLen || !strings.HasPrefix(string(b[:sceneHeaderLen]), sceneHeader) {
+		return nil, fmt.Errorf("unsupported file, expected %q header", sceneHeader)
+	}
+
+	s := &Scene{header: b[:sceneHeaderLen], parents: make(map[crdtID]crdtID)}
+	r := &reader{buf: b, pos: sceneHeaderLen}
+	names := make(map[crdtID]*Layer)
+	for r.remaining() > 0 {
+		start := r.pos
+		blk, err := r.readBlock()
+		if err != nil {
+			return nil, err
+		}
+		raw := rawBlock{raw: b[start:r.pos]}
+
+		switch blk.typ {
+		case blockSceneTree:
+			node, parent, err := readSceneTree(blk.data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read scene tree: %w", err)
+			}
+			s.parents[node] = parent
+			if parent == rootNode {
+				s.Layers = append(s.Layers, Layer{ID: node.String(), Visible: true, id: node})
+			}
+		case blockTreeNode:
+			l, err := readTreeNode(blk.data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tree node: %w", err)
+			}
+			names[l.id] = l
+		case blockSceneLineItem:
+			st, err := readLineItem(blk.data, blk.version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read line item: %w", err)
+			}
+			if st != nil {
+				s.Strokes++
+				s.InkCoverage += st.area / pageArea
+				raw.parent = &st.parent
+			}
+		case blockRootText:
+			t, err := readRootText(blk.data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read root text: %w", err)
+			}
+			s.Text = t
+		case blockSceneGlyphItem:
+			h, err := readGlyphItem(blk.data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read glyph item: %w", err)
+			}
+			if h != nil {
+				s.Highlights = append(s.Highlights, *h)
+				raw.parent = &h.parent
+			}
+		}
+
+		s.blocks = append(s.blocks, raw)
+	}
+
+	// Fill in the layer names and count the items in each layer.
+	for i := range s.Layers {
+		l := &s.Layers[i]
+		if n, ok := names[l.id]; ok {
+			l.Name = n.Name
+			l.Visible = n.Visible
+		}
+	}
+	for _, blk := range s.blocks {
+		if blk.parent == nil {
+			continue
+		}
+		if l := s.layerOf(*blk.parent); l != nil {
+			l.Items++
+		}
+	}
+
+	s.InkCoverage = math.Min(s.InkCoverage, 1)
+
+	return s, nil
+}
+
+// block is a single top-level block of a v6 ".rm" file.
+type block struct {
+	// typ is the type of the block, see the block* constants.
+	typ uint8
+
+	// version is the current version of the block's format.
+	version uint8
+
+	// data is a reader over the contents of the block.
+	data *reader
+}
+
+// reader is a reader for the primitive and tagged values used by the
+// v6 ".rm" format.
+type reader struct {
+	buf []byte
+	pos int
+}
+
+// errShortRead is returned when a value extends past the end of the
+// buffer being read.
+var errShortRead = errors.New("unexpected end of data")
+
+// remaining returns the number of unread bytes.
+func (r *reader) remaining() int {
+	return len(r.buf) - r.pos
+}
+
+// bytes reads n raw bytes.
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.remaining() < n {
+		return nil, errShortRead
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// uint8 reads a single byte.
+func (r *reader) uint8() (uint8, error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// uint16 reads a little-endian uint16.
+func (r *reader) uint16() (uint16, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+// uint32 reads a little-endian uint32.
+func (r *reader) uint32() (uint32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// float32 reads a little-endian float32.
+func (r *reader) float32() (float32, error) {
+	v, err := r.uint32()
+	return math.Float32frombits(v), err
+}
+
+// float64 reads a little-endian float64.
+func (r *reader) float64() (float64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+// varuint reads a LEB128 encoded unsigned integer.
+func (r *reader) varuint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := r.uint8()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("varuint overflows uint64")
+}
+
+// rawID reads an untagged [crdtID].
+func (r *reader) rawID() (crdtID, error) {
+	p1, err := r.uint8()
+	if err != nil {
+		return crdtID{}, err
+	}
+	p2, err := r.varuint()
+	if err != nil {
+		return crdtID{}, err
+	}
+	return crdtID{p1, p2}, nil
+}
+
+// readBlock reads a top-level block.
+func (r *reader) readBlock() (*block, error) {
+	length, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	// unknown, min version, current version, block type
+	hdr, err := r.bytes(4)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := r.bytes(int(length))
+	if err != nil {
+		return nil, fmt.Errorf("block of type 0x%02x: %w", hdr[3], err)
+	}
+
+	return &block{typ: hdr[3], version: hdr[2], data: &reader{buf: data}}, nil
+}
+
+// peekTag returns true if the next value is tagged with the given index
+// and type. It does not advance the reader.
+func (r *reader) peekTag(index uint64, typ uint8) bool {
+	if r.remaining() == 0 {
+		return false
+	}
+
+	pos := r.pos
+	defer func() { r.pos = pos }()
+
+	v, err := r.varuint()
+	if err != nil {
+		return false
+	}
+	return v>>4 == index && uint8(v&0xF) == typ
+}
+
+// tag reads a tag and ensures it matches the given index and type.
+func (r *reader) tag(index uint64, typ uint8) error {
+	v, err := r.varuint()
+	if err != nil {
+		return err
+	}
+	if v>>4 != index || uint8(v&0xF) != typ {
+		return fmt.Errorf("expected tag %d/0x%x, got %d/0x%x", index, typ, v>>4, v&0xF)
+	}
+	return nil
+}
+
+// id reads a tagged [crdtID].
+func (r *reader) id(index uint64) (crdtID, error) {
+	if err := r.tag(index, tagID); err != nil {
+		return crdtID{}, err
+	}
+	return r.rawID()
+}
+
+// bool reads a tagged boolean.
+func (r *reader) bool(index uint64) (bool, error) {
+	if err := r.tag(index, tagByte1); err != nil {
+		return false, err
+	}
+	b, err := r.uint8()
+	return b != 0, err
+}
+
+// int reads a tagged uint32.
+func (r *reader) int(index uint64) (uint32, error) {
+	if err := r.tag(index, tagByte4); err != nil {
+		return 0, err
+	}
+	return r.uint32()
+}
+
+// double reads a tagged float64.
+func (r *reader) double(index uint64) (float64, error) {
+	if err := r.tag(index, tagByte8); err != nil {
+		return 0, err
+	}
+	return r.float64()
+}
+
+// subblock reads a tagged, length-prefixed subblock and returns a reader
+// over its contents.
+func (r *reader) subblock(index uint64) (*reader, error) {
+	if err := r.tag(index, tagLength4); err != nil {
+		return nil, err
+	}
+	length, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.bytes(int(length))
+	if err != nil {
+		return nil, err
+	}
+	return &reader{buf: b}, nil
+}
+
+// rawString reads an untagged, length-prefixed string.
+func (r *reader) rawString() (string, error) {
+	length, err := r.varuint()
+	if err != nil {
+		return "", err
+	}
+
+	// is_ascii, we always decode as UTF-8.
+	if _, err := r.uint8(); err != nil {
+		return "", err
+	}
+
+	if length > math.MaxInt32 {
+		return "", errShortRead
+	}
+	b, err := r.bytes(int(length))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// string reads a tagged string.
+func (r *reader) string(index uint64) (string, error) {
+	sb, err := r.subblock(index)
+	if err != nil {
+		return "", err
+	}
+	return sb.rawString()
+}
+
+// sequenceItem is the common header of an item in a CRDT sequence.
+type sequenceItem struct {
+	parentID crdtID
+	itemID   crdtID
+	leftID   crdtID
+	rightID  crdtID
+	deleted  uint32
+
+	// value is a reader over the value of the item. It is nil if the item
+	// has been deleted.
+	value *reader
+}
+
+// readSequenceItem reads a scene item block header. If the item has a
+// value, the value reader is positioned after the item type byte, which
+// must match wantType.
+func readSequenceItem(r *reader, wantType uint8) (*sequenceItem, error) {
+	var err error
+	it := &sequenceItem{}
+	if it.parentID, err = r.id(1); err != nil {
+		return nil, err
+	}
+	if it.itemID, err = r.id(2); err != nil {
+		return nil, err
+	}
+	if it.leftID, err = r.id(3); err != nil {
+		return nil, err
+	}
+	if it.rightID, err = r.id(4); err != nil {
+		return nil, err
+	}
+	if it.deleted, err = r.int(5); err != nil {
+		return nil, err
+	}
+
+	if !r.peekTag(6, tagLength4) {
+		return it, nil
+	}
+
+	if it.value, err = r.subblock(6); err != nil {
+		return nil, err
+	}
+	typ, err := it.value.uint8()
+	if err != nil {
+		return nil, err
+	}
+	if typ != wantType {
+		return nil, fmt.Errorf("expected item type %d, got %d", wantType, typ)
+	}
+
+	return it, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DocumentInfo describes a document available from a [Source].
+type DocumentInfo struct {
+	// ID is the ID of the document.
+	ID string
+
+	// Name is the name of the document, as shown on the device.
+	Name string
+
+	// Path is the slash separated path of the document, including the
+	// folders it's in, e.g., "Journals/Daily".
+	Path string
+
+	// Version is incremented every time the document changes. Zero if the
+	// source doesn't track versions.
+	Version int
+
+	// Modified is when the document was last modified, if known.
+	Modified time.Time
+}
+
+// Source is somewhere notebooks can be read from, e.g., the reMarkable
+// cloud ([Client]) or a copy of the device's document tree ([Local]).
+type Source interface {
+	// ListDocuments returns the notebooks available from the source,
+	// sorted by path. Folders and trashed documents aren't included.
+	ListDocuments(ctx context.Context) ([]DocumentInfo, error)
+
+	// DownloadDocument fetches a document and reads it. Call
+	// [Document.Close] once done with it.
+	DownloadDocument(ctx context.Context, info *DocumentInfo) (*Document, error)
+}
+
+// FindDocument returns the document with the provided path. If no path
+// matches, the first document with the provided name is returned.
+func FindDocument(ctx context.Context, src Source, name string) (*DocumentInfo, error) {
+	docs, err := src.ListDocuments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	var found *DocumentInfo
+	for i := range docs {
+		if docs[i].Path == name {
+			return &docs[i], nil
+		}
+		if found == nil && docs[i].Name == name {
+			found = &docs[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("document %q not found", name)
+	}
+
+	return found, nil
+}
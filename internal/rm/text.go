@@ -0,0 +1,553 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParagraphStyle is the style of a paragraph of typed text.
+type ParagraphStyle uint8
+
+// Contains the paragraph styles supported by the reMarkable.
+const (
+	StyleBasic ParagraphStyle = iota
+	StylePlain
+	StyleHeading
+	StyleBold
+	StyleBullet
+	StyleBullet2
+	StyleCheckbox
+	StyleCheckboxChecked
+)
+
+// Inline formatting codes that can be present in a text sequence.
+const (
+	formatBoldStart   = 1
+	formatBoldEnd     = 2
+	formatItalicStart = 3
+	formatItalicEnd   = 4
+)
+
+// maxTextLength is the maximum number of characters, including deleted
+// ones, in the text sequence of a page. Far more than fits on a page, it
+// keeps a corrupt file from using up all memory.
+const maxTextLength = 1 << 20
+
+// Text is the typed text on a page.
+type Text struct {
+	// Paragraphs is the list of paragraphs, in order.
+	Paragraphs []Paragraph
+}
+
+// Paragraph is a single paragraph of typed text.
+type Paragraph struct {
+	// Style is the paragraph style of this paragraph.
+	Style ParagraphStyle
+
+	// Spans is the list of spans that make up the contents of the
+	// paragraph.
+	Spans []Span
+}
+
+// Span is a run of text with the same inline formatting.
+type Span struct {
+	Text   string
+	Bold   bool
+	Italic bool
+}
+
+// String returns the paragraph contents without formatting.
+func (p *Paragraph) String() string {
+	var sb strings.Builder
+	for _, s := range p.Spans {
+		sb.WriteString(s.Text)
+	}
+	return sb.String()
+}
+
+// Highlight is a highlighted range of text on a page.
+type Highlight struct {
+	// Text is the text that was highlighted.
+	Text string
+
+	// Color is the reMarkable color ID of the highlighter.
+	Color uint32
+
+	// Start is the offset of the highlight in the underlying document's
+	// text, if known.
+	Start uint32
+
+	// Top is the y coordinate of the first rectangle of the highlight,
+	// used for ordering highlights on a page.
+	Top float64
+
+	// parent is the scene tree node containing the highlight.
+	parent crdtID
+}
+
+// textChar is a single character (or formatting code) of a text
+// sequence after it has been expanded.
+type textChar struct {
+	id, left, right crdtID
+	deleted         bool
+
+	// r is the character. Only valid if format is zero.
+	r rune
+
+	// format is an inline formatting code, if non-zero.
+	format uint32
+}
+
+// readRootText reads a RootText block.
+func readRootText(r *reader) (*Text, error) {
+	if _, err := r.id(1); err != nil {
+		return nil, err
+	}
+
+	body, err := r.subblock(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Text items.
+	itemsBlk, err := body.subblock(1)
+	if err != nil {
+		return nil, err
+	}
+	itemsBlk, err = itemsBlk.subblock(1)
+	if err != nil {
+		return nil, err
+	}
+	n, err := itemsBlk.varuint()
+	if err != nil {
+		return nil, err
+	}
+	var chars []textChar
+	for range n {
+		cs, err := readTextItem(itemsBlk)
+		if err != nil {
+			return nil, err
+		}
+		chars = append(chars, cs...)
+		if len(chars) > maxTextLength {
+			return nil, fmt.Errorf("text is longer than the maximum of %d characters", maxTextLength)
+		}
+	}
+
+	// Paragraph styles, keyed by the ID of the character that starts the
+	// paragraph.
+	fmtBlk, err := body.subblock(2)
+	if err != nil {
+		return nil, err
+	}
+	fmtBlk, err = fmtBlk.subblock(1)
+	if err != nil {
+		return nil, err
+	}
+	n, err = fmtBlk.varuint()
+	if err != nil {
+		return nil, err
+	}
+	styles := make(map[crdtID]ParagraphStyle, n)
+	for range n {
+		charID, err := fmtBlk.rawID()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fmtBlk.id(1); err != nil {
+			return nil, err
+		}
+		sb, err := fmtBlk.subblock(2)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := sb.uint8(); err != nil {
+			return nil, err
+		}
+		style, err := sb.uint8()
+		if err != nil {
+			return nil, err
+		}
+		styles[charID] = ParagraphStyle(style)
+	}
+
+	ordered, err := sortChars(chars)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildText(ordered, styles), nil
+}
+
+// readTextItem reads a single item of the text sequence and expands it
+// into individual characters.
+func readTextItem(r *reader) ([]textChar, error) {
+	sb, err := r.subblock(0)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := sb.id(2)
+	if err != nil {
+		return nil, err
+	}
+	left, err := sb.id(3)
+	if err != nil {
+		return nil, err
+	}
+	right, err := sb.id(4)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := sb.int(5)
+	if err != nil {
+		return nil, err
+	}
+	// Each deleted character is expanded below, so a corrupt count
+	// mustn't be trusted.
+	if deleted > maxTextLength {
+		return nil, fmt.Errorf("text item deletes %d characters, more than the maximum of %d", deleted, maxTextLength)
+	}
+
+	var text string
+	var format uint32
+	if sb.peekTag(6, tagLength4) {
+		vb, err := sb.subblock(6)
+		if err != nil {
+			return nil, err
+		}
+		if text, err = vb.rawString(); err != nil {
+			return nil, err
+		}
+		if vb.peekTag(2, tagByte4) {
+			if format, err = vb.int(2); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Expand the item into one entry per character, each with their own
+	// ID, so that other items can reference them.
+	var chars []textChar
+	switch {
+	case deleted > 0:
+		for range deleted {
+			chars = append(chars, textChar{deleted: true})
+		}
+	case format != 0 && text == "":
+		chars = append(chars, textChar{format: format})
+	default:
+		for _, c := range text {
+			chars = append(chars, textChar{r: c})
+		}
+	}
+	for i := range chars {
+		chars[i].id = crdtID{id.Part1, id.Part2 + uint64(i)}
+		chars[i].left = left
+		chars[i].right = right
+		if i > 0 {
+			chars[i].left = chars[i-1].id
+		}
+		if i < len(chars)-1 {
+			chars[i].right = crdtID{id.Part1, id.Part2 + uint64(i) + 1}
+		}
+	}
+
+	return chars, nil
+}
+
+// seqKey is a node in the graph used to order a CRDT sequence. The start
+// and end of the sequence are represented by the start and end fields.
+type seqKey struct {
+	id    crdtID
+	start bool
+	end   bool
+}
+
+// sortChars orders the characters of a CRDT sequence based on their
+// left and right references.
+func sortChars(chars []textChar) ([]textChar, error) {
+	byID := make(map[crdtID]textChar, len(chars))
+	for _, c := range chars {
+		byID[c.id] = c
+	}
+
+	side := func(id crdtID, left bool) seqKey {
+		if id == endMarker {
+			return seqKey{start: left, end: !left}
+		}
+		return seqKey{id: id}
+	}
+
+	// deps maps a node to the nodes that must come before it.
+	deps := make(map[seqKey]map[seqKey]struct{})
+	add := func(k, dep seqKey) {
+		if deps[k] == nil {
+			deps[k] = make(map[seqKey]struct{})
+		}
+		if _, ok := deps[dep]; !ok {
+			deps[dep] = make(map[seqKey]struct{})
+		}
+		deps[k][dep] = struct{}{}
+	}
+	for _, c := range byID {
+		add(seqKey{id: c.id}, side(c.left, true))
+		add(side(c.right, false), seqKey{id: c.id})
+	}
+
+	out := make([]textChar, 0, len(byID))
+	for len(deps) > 0 {
+		var next []seqKey
+		for k, d := range deps {
+			if len(d) == 0 {
+				next = append(next, k)
+			}
+		}
+		if len(next) == 0 {
+			return nil, fmt.Errorf("text sequence contains a cycle")
+		}
+		sort.Slice(next, func(i, j int) bool { return next[i].id.less(next[j].id) })
+
+		for _, k := range next {
+			delete(deps, k)
+			if c, ok := byID[k.id]; ok && !k.start && !k.end {
+				out = append(out, c)
+			}
+		}
+		for _, d := range deps {
+			for _, k := range next {
+				delete(d, k)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// buildText converts an ordered list of characters into paragraphs.
+func buildText(chars []textChar, styles map[crdtID]ParagraphStyle) *Text {
+	t := &Text{}
+
+	var bold, italic bool
+	var cur strings.Builder
+	para := Paragraph{Style: StylePlain}
+	if s, ok := styles[endMarker]; ok {
+		para.Style = s
+	}
+
+	flush := func() {
+		if cur.Len() == 0 {
+			return
+		}
+		para.Spans = append(para.Spans, Span{Text: cur.String(), Bold: bold, Italic: italic})
+		cur.Reset()
+	}
+
+	for _, c := range chars {
+		if c.deleted {
+			continue
+		}
+
+		switch c.format {
+		case formatBoldStart, formatBoldEnd:
+			flush()
+			bold = c.format == formatBoldStart
+			continue
+		case formatItalicStart, formatItalicEnd:
+			flush()
+			italic = c.format == formatItalicStart
+			continue
+		}
+
+		if c.r == '\n' {
+			flush()
+			t.Paragraphs = append(t.Paragraphs, para)
+			para = Paragraph{Style: StylePlain}
+			if s, ok := styles[c.id]; ok {
+				para.Style = s
+			}
+			continue
+		}
+
+		cur.WriteRune(c.r)
+	}
+	flush()
+	t.Paragraphs = append(t.Paragraphs, para)
+
+	return t
+}
+
+// readGlyphItem reads a SceneGlyphItem block. If the item has been
+// deleted, nil is returned.
+func readGlyphItem(r *reader) (*Highlight, error) {
+	it, err := readSequenceItem(r, 0x01)
+	if err != nil {
+		return nil, err
+	}
+	if it.value == nil {
+		return nil, nil //nolint:nilnil // Why: Deleted items have no value.
+	}
+	v := it.value
+
+	h := &Highlight{parent: it.parentID}
+	if v.peekTag(2, tagByte4) {
+		if h.Start, err = v.int(2); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := v.int(3); err != nil { // length
+		return nil, err
+	}
+	if h.Color, err = v.int(4); err != nil {
+		return nil, err
+	}
+	if v.peekTag(5, tagLength4) {
+		if h.Text, err = v.string(5); err != nil {
+			return nil, err
+		}
+	}
+
+	rects, err := v.subblock(6)
+	if err != nil {
+		return nil, err
+	}
+	n, err := rects.varuint()
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		// x, then y.
+		if _, err := rects.float64(); err != nil {
+			return nil, err
+		}
+		if h.Top, err = rects.float64(); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// Markdown converts the text and highlights of the scene into Markdown.
+// An empty string is returned if the page has neither.
+func (s *Scene) Markdown() string {
+	var blocks []string
+
+	if s.Text != nil {
+		if md := s.Text.Markdown(); md != "" {
+			blocks = append(blocks, md)
+		}
+	}
+
+	highlights := make([]Highlight, 0, len(s.Highlights))
+	for _, h := range s.Highlights {
+		if strings.TrimSpace(h.Text) != "" {
+			highlights = append(highlights, h)
+		}
+	}
+	sort.SliceStable(highlights, func(i, j int) bool {
+		return highlights[i].Top < highlights[j].Top
+	})
+	for _, h := range highlights {
+		blocks = append(blocks, "> "+strings.Join(strings.Fields(h.Text), " "))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// Markdown converts the text into Markdown.
+func (t *Text) Markdown() string {
+	var sb strings.Builder
+
+	prevList := false
+	for i := range t.Paragraphs {
+		p := &t.Paragraphs[i]
+		contents := strings.TrimSpace(p.markdownSpans())
+		if contents == "" {
+			continue
+		}
+
+		var line string
+		isList := false
+		switch p.Style {
+		case StyleHeading:
+			line = "# " + contents
+		case StyleBold:
+			line = "**" + contents + "**"
+		case StyleBullet:
+			line, isList = "- "+contents, true
+		case StyleBullet2:
+			line, isList = "  - "+contents, true
+		case StyleCheckbox:
+			line, isList = "- [ ] "+contents, true
+		case StyleCheckboxChecked:
+			line, isList = "- [x] "+contents, true
+		case StyleBasic, StylePlain:
+			line = contents
+		default:
+			line = contents
+		}
+
+		// Consecutive list items are kept together, everything else is
+		// separated by a blank line.
+		if sb.Len() > 0 {
+			if isList && prevList {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(line)
+		prevList = isList
+	}
+
+	return sb.String()
+}
+
+// markdownSpans renders the spans of the paragraph with their inline
+// formatting as Markdown.
+func (p *Paragraph) markdownSpans() string {
+	var sb strings.Builder
+	for _, s := range p.Spans {
+		text := s.Text
+		if strings.TrimSpace(text) == "" {
+			sb.WriteString(text)
+			continue
+		}
+
+		// Markdown emphasis can't start or end with whitespace, so move it
+		// outside of the markers.
+		lead := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+		trail := text[len(strings.TrimRight(text, " \t")):]
+		text = strings.TrimSpace(text)
+
+		switch {
+		case s.Bold && s.Italic:
+			text = "***" + text + "***"
+		case s.Bold:
+			text = "**" + text + "**"
+		case s.Italic:
+			text = "*" + text + "*"
+		}
+		sb.WriteString(lead + text + trail)
+	}
+	return sb.String()
+}
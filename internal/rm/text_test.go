@@ -0,0 +1,68 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package rm
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// textItem encodes a text item deleting the provided number of
+// characters.
+func textItem(deleted uint32) []byte {
+	body := []byte{
+		0x2F, 1, 10, // id
+		0x3F, 0, 0, // left
+		0x4F, 0, 0, // right
+		0x54, // deleted
+	}
+	body = binary.LittleEndian.AppendUint32(body, deleted)
+
+	b := []byte{0x0C}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(body)))
+	return append(b, body...)
+}
+
+func TestReadTextItemDeleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    []byte
+		want    int
+		wantErr bool
+	}{
+		{"deleted characters", append(textItem(3), make([]byte, 8)...), 3, false},
+		{"deletion is the last item", textItem(1000), 1000, false},
+		{"count over the maximum", textItem(0xFFFFFFFF), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chars, err := readTextItem(&reader{buf: tt.item})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readTextItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(chars) != tt.want {
+				t.Errorf("readTextItem() returned %d characters, want %d", len(chars), tt.want)
+			}
+			for _, c := range chars {
+				if !c.deleted {
+					t.Errorf("character %v isn't deleted", c.id)
+				}
+			}
+		})
+	}
+}
@@ -18,11 +18,17 @@
 package rm
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 // Zip is a representation of the inside of a "rm" file version 6.
@@ -33,20 +39,47 @@ type Zip struct {
 	// Metadata is the contents of the "<id>.metadata" file.
 	Metadata Metadata
 
+	// Content is the contents of the "<id>.content" file. Nil if the
+	// document doesn't have one.
+	Content *Content
+
+	// PageData is the contents of the "<id>.pagedata" file, if present.
+	PageData PageData
+
 	// Pages is a list of pages in the zip file.
 	Pages []Page
 }
 
-// Metadata is the metadata of a [Page].
+// Metadata is the contents of the "<id>.metadata" file of a document.
+// Timestamps are milliseconds since the unix epoch, as strings.
 type Metadata struct {
-	CreatedTime    string `json:"createdTime"`
-	LastModified   string `json:"lastModified"`
-	LastOpened     string `json:"lastOpened"`
-	LastOpenedPage int    `json:"lastOpenedPage"`
-	Parent         string `json:"parent"`
-	Pinned         bool   `json:"pinned"`
-	Type           string `json:"type"`
-	VisibleName    string `json:"visibleName"`
+	CreatedTime      string `json:"createdTime"`
+	Deleted          bool   `json:"deleted,omitempty"`
+	LastModified     string `json:"lastModified"`
+	LastOpened       string `json:"lastOpened"`
+	LastOpenedPage   int    `json:"lastOpenedPage"`
+	MetadataModified bool   `json:"metadatamodified,omitempty"`
+	Modified         bool   `json:"modified,omitempty"`
+	Parent           string `json:"parent"`
+	Pinned           bool   `json:"pinned"`
+	Synced           bool   `json:"synced,omitempty"`
+	Type             string `json:"type"`
+	Version          int    `json:"version,omitempty"`
+	VisibleName      string `json:"visibleName"`
+
+	extra jsonExtra
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (m *Metadata) UnmarshalJSON(b []byte) error {
+	type plain Metadata
+	return m.extra.unmarshal(b, (*plain)(m))
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (m *Metadata) MarshalJSON() ([]byte, error) {
+	type plain Metadata
+	return m.extra.marshal((*plain)(m))
 }
 
 // Page represents a page in a remarkable journal.
@@ -54,19 +87,126 @@ type Page struct {
 	// ID is the ID of the page.
 	ID string
 
-	// Path is the path to the page. This is the "<id>.rm" file.
+	// Path is the path to the page. This is the "<id>.rm" file. Empty if
+	// the page's contents weren't downloaded, see [Client.Unchanged].
 	Path string
 
-	// PNGPath is the path to the rendered PNG file. To set, call "Render"
-	// on the page.
-	PNGPath string
+	// Hash is the hex encoded SHA-256 of the page's ".rm" file. Empty if
+	// its contents weren't downloaded.
+	Hash string
+
+	// Index is the zero-based position of the page in the document. This
+	// counts pages that haven't been written on, so it matches the page
+	// numbers shown on the device.
+	Index int
+
+	// Modified is when the page was last modified.
+	Modified time.Time
+
+	// Template is the name of the page's template, e.g., "Blank", if
+	// known.
+	Template string
+
+	// PNGPaths is the list of paths to the rendered PNG files. There is
+	// one per layer when rendering layers separately, otherwise only one.
+	// To set, call "Render" on the page.
+	PNGPaths []string
+}
+
+// RenderOptions controls which layers of a page are rendered.
+type RenderOptions struct {
+	// VisibleLayersOnly skips layers that are hidden on the device.
+	VisibleLayersOnly bool
+
+	// ExcludeLayers skips layers whose name matches the expression.
+	ExcludeLayers *regexp.Regexp
+
+	// PerLayer renders each layer into its own image.
+	PerLayer bool
+}
+
+// includes returns true if the layer should be rendered.
+func (o *RenderOptions) includes(l *Layer) bool {
+	if o.VisibleLayersOnly && !l.Visible {
+		return false
+	}
+	if o.ExcludeLayers != nil && o.ExcludeLayers.MatchString(l.Name) {
+		return false
+	}
+	return true
+}
+
+// Render populates the PNGPaths field of the page by rendering the page
+// to PNG files. If every layer is filtered out by opts and the page has
+// no typed text, PNGPaths will be empty.
+func (p *Page) Render(ctx context.Context, opts *RenderOptions) error {
+	base := strings.TrimSuffix(p.Path, ".rm")
+	p.PNGPaths = nil
+
+	// Nothing to filter, render the page as-is.
+	if opts == nil || (!opts.VisibleLayersOnly && opts.ExcludeLayers == nil && !opts.PerLayer) {
+		dest := base + ".png"
+		if err := RenderRmToPng(ctx, p.Path, dest); err != nil {
+			return err
+		}
+		p.PNGPaths = []string{dest}
+		return nil
+	}
+
+	scene, err := p.Scene()
+	if err != nil {
+		return fmt.Errorf("failed to read layers: %w", err)
+	}
+
+	layers := make([]Layer, 0, len(scene.Layers))
+	for i := range scene.Layers {
+		if l := &scene.Layers[i]; l.Items > 0 && opts.includes(l) {
+			layers = append(layers, *l)
+		}
+	}
+	hasText := scene.Text != nil && scene.Text.Markdown() != ""
+	if len(layers) == 0 && !hasText {
+		return nil
+	}
+
+	// Each group of layers is written to a filtered copy of the page and
+	// rendered into its own image. Typed text isn't part of a layer, so a
+	// page with only typed text is rendered without any layers.
+	groups := [][]Layer{layers}
+	if opts.PerLayer && len(layers) > 0 {
+		groups = make([][]Layer, 0, len(layers))
+		for _, l := range layers {
+			groups = append(groups, []Layer{l})
+		}
+	}
+	for i, g := range groups {
+		src := fmt.Sprintf("%s.layers-%d.rm", base, i)
+		if err := scene.WriteLayers(src, g...); err != nil {
+			return fmt.Errorf("failed to write filtered page: %w", err)
+		}
+
+		dest := strings.TrimSuffix(src, ".rm") + ".png"
+		if err := RenderRmToPng(ctx, src, dest); err != nil {
+			return err
+		}
+		p.PNGPaths = append(p.PNGPaths, dest)
+	}
+
+	return nil
 }
 
-// Render populates the PNGPath field of the page by rendering the page
-// to a PNG file.
-func (p *Page) Render() error {
-	p.PNGPath = fmt.Sprintf("%s.png", strings.TrimSuffix(p.Path, ".rm"))
-	return RenderRmToPng(p.Path, p.PNGPath)
+// Scene reads and parses the page's ".rm" file.
+func (p *Page) Scene() (*Scene, error) {
+	return ReadScene(p.Path)
+}
+
+// Layers returns the layers of the page.
+func (p *Page) Layers() ([]Layer, error) {
+	scene, err := p.Scene()
+	if err != nil {
+		return nil, err
+	}
+	return scene.Layers, nil
 }
 
 // newZipFromDir creates a new Zip from a directory containing a
@@ -108,21 +248,78 @@ func newZipFromDir(path string) (*Zip, error) {
 		return nil, err
 	}
 
+	z.ID = id
+
 	// Find all the pages.
 	pageFiles, err := os.ReadDir(filepath.Join(path, id))
 	if err != nil {
 		return nil, err
 	}
 
+	pages := make(map[string]Page)
 	for _, f := range pageFiles {
 		if !strings.HasSuffix(f.Name(), ".rm") {
 			continue
 		}
 
-		z.Pages = append(z.Pages, Page{
-			ID:   strings.TrimSuffix(f.Name(), ".rm"),
-			Path: filepath.Join(path, id, f.Name()),
-		})
+		inf, err := f.Info()
+		if err != nil {
+			return nil, err
+		}
+		p := Page{ID: strings.TrimSuffix(f.Name(), ".rm"), Modified: inf.ModTime()}
+
+		// Empty files are placeholders for pages that weren't downloaded.
+		if inf.Size() > 0 {
+			p.Path = filepath.Join(path, id, f.Name())
+			if p.Hash, err = fileHash(p.Path); err != nil {
+				return nil, err
+			}
+		}
+		pages[p.ID] = p
+	}
+
+	z.Content, err = ReadContent(filepath.Join(path, id+".content"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read content file: %w", err)
+	}
+	z.PageData, err = ReadPageData(filepath.Join(path, id+".pagedata"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read pagedata file: %w", err)
+	}
+
+	// Order the pages based on the content file. Pages that haven't been
+	// written on have no ".rm" file, but still take up an index.
+	var order []ContentPage
+	if z.Content != nil {
+		order = z.Content.OrderedPages()
+	}
+	for i, cp := range order {
+		p, ok := pages[cp.ID]
+		if !ok {
+			continue
+		}
+		delete(pages, cp.ID)
+
+		p.Index = i
+		if !cp.Modified.IsZero() {
+			p.Modified = cp.Modified
+		}
+		p.Template = cp.Template
+		if p.Template == "" && i < len(z.PageData) {
+			p.Template = z.PageData[i]
+		}
+		z.Pages = append(z.Pages, p)
+	}
+
+	// Anything not in the content file goes at the end.
+	rest := make([]Page, 0, len(pages))
+	for _, p := range pages {
+		rest = append(rest, p)
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i].ID < rest[j].ID })
+	for i := range rest {
+		rest[i].Index = len(order) + i
+		z.Pages = append(z.Pages, rest[i])
 	}
 
 	return z, nil
@@ -0,0 +1,456 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package state
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltVersion is the version of the schema of the database.
+const boltVersion = 1
+
+// maxAttempts is the number of attempts kept per page, older ones are
+// removed.
+const maxAttempts = 50
+
+// Contains the buckets of the database.
+var (
+	// metaBucket holds information about the database, e.g., its
+	// version.
+	metaBucket = []byte("meta")
+
+	// pagesBucket maps page IDs to JSON encoded [Page]s.
+	pagesBucket = []byte("pages")
+
+	// documentsBucket maps document IDs to JSON encoded [Document]s.
+	documentsBucket = []byte("documents")
+
+	// attemptsBucket holds a bucket per page ID, mapping sequence numbers
+	// to JSON encoded [Attempt]s.
+	attemptsBucket = []byte("attempts")
+)
+
+// importedKey is set in [metaBucket] once the state file has been
+// imported.
+var importedKey = []byte("imported")
+
+// Bolt is a [Store] keeping the state in an embedded bbolt database.
+// Every change is committed in its own transaction, so that the whole
+// state isn't rewritten for a single page. Create with [OpenBolt].
+//
+// bbolt locks the database file for as long as it's open, so it's only
+// opened for the duration of a transaction. This allows reading the
+// state, e.g., through "status", while a sync or the daemon holds it.
+type Bolt struct {
+	log      *slog.Logger
+	path     string
+	readOnly bool
+
+	// lock is the lock on the database, nil if read-only.
+	lock *FileLock
+
+	// mu serializes transactions, the database can only be opened once
+	// at a time for writing.
+	mu sync.Mutex
+}
+
+// OpenBolt opens the database at the provided path, created if it
+// doesn't exist. Unless opened as read-only, the database is locked until
+// closed. A state file in the same directory is imported into it once,
+// the import is retried on the next open if it fails.
+func OpenBolt(log *slog.Logger, path string, readOnly bool) (_ *Bolt, err error) {
+	if path == "" {
+		return nil, errors.New("state database path is required")
+	}
+
+	b := &Bolt{log: log, path: path, readOnly: readOnly}
+	if !readOnly {
+		if b.lock, err = Lock(path); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if err != nil {
+			b.Close() //nolint:errcheck // Why: Best effort.
+		}
+	}()
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && readOnly {
+		return nil, fmt.Errorf("state database %s: %w", path, os.ErrNotExist)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	if readOnly {
+		return b, b.checkVersion()
+	}
+
+	var imported bool
+	if err := b.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{metaBucket, pagesBucket, documentsBucket, attemptsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		meta := tx.Bucket(metaBucket)
+		if meta.Get([]byte("version")) == nil {
+			if err := meta.Put([]byte("version"), []byte(strconv.Itoa(boltVersion))); err != nil {
+				return err
+			}
+		}
+
+		// Databases created before the marker was added already imported
+		// the state file if they have any pages.
+		imported = meta.Get(importedKey) != nil
+		if k, _ := tx.Bucket(pagesBucket).Cursor().First(); !imported && k != nil {
+			imported = true
+			return meta.Put(importedKey, []byte("1"))
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to initialize state database: %w", err)
+	}
+	if err := b.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	if !imported {
+		if err := b.importFile(filepath.Join(filepath.Dir(path), FileName)); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// checkVersion returns an error if the database was created by a newer
+// version.
+func (b *Bolt) checkVersion() error {
+	return b.view(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if meta == nil {
+			return errors.New("state database isn't initialized")
+		}
+		v, err := strconv.Atoi(string(meta.Get([]byte("version"))))
+		if err != nil {
+			return fmt.Errorf("invalid state database version: %w", err)
+		}
+		if v > boltVersion {
+			return fmt.Errorf("state database version %d is newer than the supported version %d, upgrade remarkabledayone",
+				v, boltVersion)
+		}
+		return nil
+	})
+}
+
+// importFile imports the state file at the provided path, if it exists,
+// and records that it was imported. Nothing is recorded if it fails, so
+// that it's tried again.
+func (b *Bolt) importFile(path string) error {
+	st := New()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		if st, err = loadFile(b.log, path); err != nil {
+			return err
+		}
+	}
+
+	if err := b.update(func(tx *bolt.Tx) error {
+		if err := importState(tx, st); err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Put(importedKey, []byte("1"))
+	}); err != nil {
+		return fmt.Errorf("failed to import state file: %w", err)
+	}
+	if len(st.Pages) > 0 || len(st.Documents) > 0 {
+		b.log.Info("imported state file into database", "from", path, "to", b.path, "pages", len(st.Pages))
+	}
+	return nil
+}
+
+// Path implements [Store].
+func (b *Bolt) Path() string {
+	return b.path
+}
+
+// Pages implements [Store].
+func (b *Bolt) Pages() (map[string]*Page, error) {
+	pages := make(map[string]*Page)
+	err := b.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(pagesBucket).ForEach(func(k, v []byte) error {
+			p := &Page{}
+			if err := json.Unmarshal(v, p); err != nil {
+				return fmt.Errorf("failed to decode page %s: %w", k, err)
+			}
+			pages[string(k)] = p
+			return nil
+		})
+	})
+	return pages, err
+}
+
+// Page implements [Store].
+func (b *Bolt) Page(id string) (*Page, error) {
+	var p *Page
+	err := b.view(func(tx *bolt.Tx) error {
+		var err error
+		p, err = getJSON[Page](tx.Bucket(pagesBucket), id)
+		return err
+	})
+	return p, err
+}
+
+// UpdatePage implements [Store].
+func (b *Bolt) UpdatePage(id string, attempt *Attempt, fn func(p *Page)) error {
+	return b.update(func(tx *bolt.Tx) error {
+		pages := tx.Bucket(pagesBucket)
+		p, err := getJSON[Page](pages, id)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			p = &Page{}
+		}
+		fn(p)
+		if err := putJSON(pages, id, p); err != nil {
+			return err
+		}
+
+		if attempt == nil {
+			return nil
+		}
+		return addAttempt(tx, id, attempt)
+	})
+}
+
+// addAttempt records an attempt to sync the page with the provided ID,
+// removing the oldest ones over [maxAttempts].
+func addAttempt(tx *bolt.Tx, id string, attempt *Attempt) error {
+	attempts, err := tx.Bucket(attemptsBucket).CreateBucketIfNotExists([]byte(id))
+	if err != nil {
+		return err
+	}
+	seq, err := attempts.NextSequence()
+	if err != nil {
+		return err
+	}
+	if err := putJSON(attempts, string(binary.BigEndian.AppendUint64(nil, seq)), attempt); err != nil {
+		return err
+	}
+
+	// Keys are ordered by sequence, so the oldest ones come first.
+	var keys [][]byte
+	c := attempts.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for i := 0; i < len(keys)-maxAttempts; i++ {
+		if err := attempts.Delete(keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePages implements [Store]. The attempts of the pages are removed
+// too.
+func (b *Bolt) DeletePages(ids ...string) error {
+	return b.update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			if err := tx.Bucket(pagesBucket).Delete([]byte(id)); err != nil {
+				return err
+			}
+			err := tx.Bucket(attemptsBucket).DeleteBucket([]byte(id))
+			if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Attempts implements [Store].
+func (b *Bolt) Attempts(id string) ([]*Attempt, error) {
+	var out []*Attempt
+	err := b.view(func(tx *bolt.Tx) error {
+		attempts := tx.Bucket(attemptsBucket).Bucket([]byte(id))
+		if attempts == nil {
+			return nil
+		}
+		return attempts.ForEach(func(_, v []byte) error {
+			a := &Attempt{}
+			if err := json.Unmarshal(v, a); err != nil {
+				return fmt.Errorf("failed to decode attempt of page %s: %w", id, err)
+			}
+			out = append(out, a)
+			return nil
+		})
+	})
+	return out, err
+}
+
+// Documents implements [Store].
+func (b *Bolt) Documents() (map[string]*Document, error) {
+	docs := make(map[string]*Document)
+	err := b.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(documentsBucket).ForEach(func(k, v []byte) error {
+			d := &Document{}
+			if err := json.Unmarshal(v, d); err != nil {
+				return fmt.Errorf("failed to decode document %s: %w", k, err)
+			}
+			docs[string(k)] = d
+			return nil
+		})
+	})
+	return docs, err
+}
+
+// PutDocument implements [Store].
+func (b *Bolt) PutDocument(id string, d *Document) error {
+	return b.update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(documentsBucket), id, d)
+	})
+}
+
+// DeleteDocuments implements [Store].
+func (b *Bolt) DeleteDocuments(ids ...string) error {
+	return b.update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			if err := tx.Bucket(documentsBucket).Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Export implements [Store].
+func (b *Bolt) Export() (*State, error) {
+	st := New()
+	var err error
+	if st.Pages, err = b.Pages(); err != nil {
+		return nil, err
+	}
+	if st.Documents, err = b.Documents(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// Import implements [Store].
+func (b *Bolt) Import(st *State) error {
+	return b.update(func(tx *bolt.Tx) error {
+		return importState(tx, st)
+	})
+}
+
+// importState writes the pages and documents of st in the transaction.
+func importState(tx *bolt.Tx, st *State) error {
+	for id, p := range st.Pages {
+		if err := putJSON(tx.Bucket(pagesBucket), id, p); err != nil {
+			return err
+		}
+	}
+	for id, d := range st.Documents {
+		if err := putJSON(tx.Bucket(documentsBucket), id, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements [Store].
+func (b *Bolt) Close() error {
+	return b.lock.Unlock()
+}
+
+// open opens the database, waiting for a transaction of another process
+// to finish.
+func (b *Bolt) open() (*bolt.DB, error) {
+	db, err := bolt.Open(b.path, 0o600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: b.readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database %s: %w", b.path, err)
+	}
+	return db, nil
+}
+
+// view runs fn in a read-only transaction.
+func (b *Bolt) view(fn func(tx *bolt.Tx) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	db, err := b.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close() //nolint:errcheck // Why: Nothing was written.
+	return db.View(fn)
+}
+
+// update runs fn in a read-write transaction.
+func (b *Bolt) update(fn func(tx *bolt.Tx) error) error {
+	if b.readOnly {
+		return ErrReadOnly
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	db, err := b.open()
+	if err != nil {
+		return err
+	}
+	if err := db.Update(fn); err != nil {
+		db.Close() //nolint:errcheck // Why: Best effort.
+		return err
+	}
+	return db.Close()
+}
+
+// getJSON decodes the value of the provided key. Nil if there's none.
+func getJSON[T any](bucket *bolt.Bucket, key string) (*T, error) {
+	v := bucket.Get([]byte(key))
+	if v == nil {
+		return nil, nil
+	}
+	out := new(T)
+	if err := json.Unmarshal(v, out); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return out, nil
+}
+
+// putJSON encodes v as the value of the provided key.
+func putJSON(bucket *bolt.Bucket, key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), b)
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package state
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testLogger returns a logger that discards everything.
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestBoltReadOnlyWhileOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DBFileName)
+	log := testLogger()
+
+	w, err := OpenBolt(log, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close() //nolint:errcheck // Why: Best effort.
+	if err := w.UpdatePage("a", nil, func(p *Page) { p.Synced = true }); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenBolt(log, path, true)
+	if err != nil {
+		t.Fatalf("read-only open while a writer has the database open failed: %v", err)
+	}
+	defer r.Close() //nolint:errcheck // Why: Best effort.
+
+	// Changes made by the writer after opening are seen.
+	if err := w.UpdatePage("b", nil, func(p *Page) { p.Synced = true }); err != nil {
+		t.Fatalf("writer failed while a reader has the database open: %v", err)
+	}
+	pages, err := r.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || !pages["a"].Synced || !pages["b"].Synced {
+		t.Errorf("Pages() = %+v, want a and b synced", pages)
+	}
+
+	if err := r.UpdatePage("c", nil, func(*Page) {}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("UpdatePage() on a read-only database error = %v, want %v", err, ErrReadOnly)
+	}
+}
+
+func TestBoltImportRetried(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DBFileName)
+	log := testLogger()
+
+	// A state file that can't be read is imported once it's fixed,
+	// rather than leaving an empty database behind.
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("pages: ["), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenBolt(log, path, false); err == nil {
+		t.Fatal("OpenBolt() with an invalid state file succeeded")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(v1State), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b, err := OpenBolt(log, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := b.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 {
+		t.Errorf("imported %d pages, want 3", len(pages))
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once imported, it isn't imported again.
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("synced_pages:\n  d: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b, err = OpenBolt(log, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close() //nolint:errcheck // Why: Best effort.
+	if p, err := b.Page("d"); err != nil || p != nil {
+		t.Errorf("state file was imported again: %+v, %v", p, err)
+	}
+}
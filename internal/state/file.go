@@ -0,0 +1,334 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a [Store] keeping the state in a YAML file, rewritten on every
+// change. It doesn't keep attempts. Create with [OpenFile].
+type File struct {
+	log *slog.Logger
+
+	// path is the location of the state file. If not set, it isn't
+	// saved.
+	path string
+
+	// readOnly is true if the state can't be modified.
+	readOnly bool
+
+	// lock is the lock on the state file, nil if read-only.
+	lock *FileLock
+
+	// mu protects st.
+	mu sync.Mutex
+	st *State
+}
+
+// OpenFile opens the state file at the provided path. Unless opened as
+// read-only, the state file is locked until closed. A new state is used
+// if the file doesn't exist, it's saved to the path. An error is
+// returned if the file exists but can't be read, rather than starting
+// over and syncing every page again. If path is empty, the state is
+// never saved.
+func OpenFile(log *slog.Logger, path string, readOnly bool) (*File, error) {
+	f := &File{log: log, path: path, readOnly: readOnly}
+	if !readOnly {
+		lock, err := Lock(path)
+		if err != nil {
+			return nil, err
+		}
+		f.lock = lock
+	}
+
+	st, err := loadFile(log, path)
+	if err != nil {
+		f.lock.Unlock() //nolint:errcheck // Why: Best effort.
+		return nil, err
+	}
+	f.st = st
+	return f, nil
+}
+
+// readStateFile reads the state file at the given path and returns the
+// state, migrated to the current version, along with the version it was
+// read as. If an error occurs, it will return the error.
+func readStateFile(path string) (*State, int, error) {
+	//#nosec:G304 // Why: Safe for our usecase.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, 0, err
+	}
+	if doc == nil {
+		return nil, 0, errors.New("file is empty")
+	}
+
+	version, err := migrate(doc)
+	if err != nil {
+		return nil, version, err
+	}
+
+	// Decoded from the migrated document rather than the file.
+	b, err = yaml.Marshal(doc)
+	if err != nil {
+		return nil, version, err
+	}
+	st := &State{}
+	if err := yaml.Unmarshal(b, st); err != nil {
+		return nil, version, err
+	}
+
+	return st, version, nil
+}
+
+// loadFile loads the state from the file at the provided path, see
+// [OpenFile].
+func loadFile(log *slog.Logger, path string) (*State, error) {
+	if path == "" {
+		return New(), nil
+	}
+
+	st, version, err := readStateFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s, fix or remove it: %w", path, err)
+	}
+
+	// Keep the original around in case the migration went wrong, it's
+	// only replaced once the state is saved.
+	if version != CurrentVersion {
+		backup, err := backupStateFile(path, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up state file before migrating it: %w", err)
+		}
+		log.Info("migrated state", "from", version, "to", CurrentVersion, "backup", backup)
+	}
+
+	if st.Pages == nil {
+		st.Pages = make(map[string]*Page)
+	}
+	if st.Documents == nil {
+		st.Documents = make(map[string]*Document)
+	}
+	return st, nil
+}
+
+// Path implements [Store].
+func (f *File) Path() string {
+	return f.path
+}
+
+// Pages implements [Store].
+func (f *File) Pages() (map[string]*Page, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pages := make(map[string]*Page, len(f.st.Pages))
+	for id, p := range f.st.Pages {
+		pages[id] = p.clone()
+	}
+	return pages, nil
+}
+
+// Page implements [Store].
+func (f *File) Page(id string) (*Page, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.st.Pages[id]
+	if !ok {
+		return nil, nil
+	}
+	return p.clone(), nil
+}
+
+// UpdatePage implements [Store]. The attempt isn't kept.
+func (f *File) UpdatePage(id string, _ *Attempt, fn func(p *Page)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	prev, existed := f.st.Pages[id]
+	p := &Page{}
+	if existed {
+		p = prev.clone()
+	}
+	fn(p)
+
+	// Restored if it can't be saved, so that the state matches the file.
+	f.st.Pages[id] = p
+	if err := f.save(); err != nil {
+		if existed {
+			f.st.Pages[id] = prev
+		} else {
+			delete(f.st.Pages, id)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeletePages implements [Store].
+func (f *File) DeletePages(ids ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	for _, id := range ids {
+		delete(f.st.Pages, id)
+	}
+	return f.save()
+}
+
+// Attempts implements [Store]. Attempts aren't kept, so it always
+// returns none.
+func (f *File) Attempts(_ string) ([]*Attempt, error) {
+	return nil, nil
+}
+
+// Documents implements [Store].
+func (f *File) Documents() (map[string]*Document, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	docs := make(map[string]*Document, len(f.st.Documents))
+	for id, d := range f.st.Documents {
+		docs[id] = d.clone()
+	}
+	return docs, nil
+}
+
+// PutDocument implements [Store].
+func (f *File) PutDocument(id string, d *Document) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	f.st.Documents[id] = d.clone()
+	return f.save()
+}
+
+// DeleteDocuments implements [Store].
+func (f *File) DeleteDocuments(ids ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	for _, id := range ids {
+		delete(f.st.Documents, id)
+	}
+	return f.save()
+}
+
+// Export implements [Store].
+func (f *File) Export() (*State, error) {
+	pages, err := f.Pages()
+	if err != nil {
+		return nil, err
+	}
+	docs, err := f.Documents()
+	if err != nil {
+		return nil, err
+	}
+	return &State{Version: CurrentVersion, Pages: pages, Documents: docs}, nil
+}
+
+// Import implements [Store].
+func (f *File) Import(st *State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	for id, p := range st.Pages {
+		f.st.Pages[id] = p.clone()
+	}
+	for id, d := range st.Documents {
+		f.st.Documents[id] = d.clone()
+	}
+	return f.save()
+}
+
+// Close implements [Store].
+func (f *File) Close() error {
+	return f.lock.Unlock()
+}
+
+// save saves the state to disk if there was a path provided when it was
+// created, f.mu must be held. Top-level directories are created if they
+// don't exist.
+//
+// The state is written to a temporary file that then replaces the state
+// file, so that the state file is never left partially written.
+func (f *File) save() error {
+	if f.path == "" {
+		f.log.Warn("not saving state, no path provided")
+		return nil
+	}
+
+	f.log.Debug("saving state", "path", f.path)
+
+	// Ensure the directory exists.
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(f.path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // Why: Best effort, fails once renamed.
+
+	if err := yaml.NewEncoder(tmp).Encode(f.st); err != nil {
+		tmp.Close() //nolint:errcheck // Why: Best effort.
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close() //nolint:errcheck // Why: Best effort.
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
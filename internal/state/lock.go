@@ -0,0 +1,99 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrLocked is returned by [Lock] when another process holds the lock.
+var ErrLocked = errors.New("state is in use by another process")
+
+// FileLock is an advisory lock on a state file. Create with [Lock].
+type FileLock struct {
+	f *os.File
+}
+
+// Lock takes an exclusive advisory lock on the state file at the
+// provided path, preventing other processes from using it at the same
+// time. The lock is held on a "<path>.lock" file next to it, so that it
+// isn't lost when the state file is replaced on save. Returns an error
+// wrapping [ErrLocked] if another process holds the lock.
+//
+// If path is empty, nothing is locked.
+func Lock(path string) (*FileLock, error) {
+	if path == "" {
+		return &FileLock{}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	lockPath := path + ".lock"
+	//#nosec:G304 // Why: Safe for our usecase.
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close() //nolint:errcheck // Why: Best effort.
+		if errors.Is(err, ErrLocked) {
+			if pid := lockOwner(lockPath); pid != "" {
+				return nil, fmt.Errorf("%w (pid %s holds %s)", ErrLocked, pid, lockPath)
+			}
+			return nil, fmt.Errorf("%w (%s is held)", ErrLocked, lockPath)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+	}
+
+	// Record who holds the lock to help when it's held unexpectedly.
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0) //nolint:errcheck // Why: Best effort.
+	}
+
+	return &FileLock{f: f}, nil
+}
+
+// lockOwner returns the process ID recorded in the lock file, if any.
+func lockOwner(lockPath string) string {
+	//#nosec:G304 // Why: Safe for our usecase.
+	b, err := os.ReadFile(lockPath)
+	if err != nil {
+		return ""
+	}
+	return string(bytes.TrimSpace(b))
+}
+
+// Unlock releases the lock. Safe to call on a nil lock.
+func (l *FileLock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
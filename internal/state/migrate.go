@@ -0,0 +1,133 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CurrentVersion is the version of the state schema. Bump it, and add a
+// migration to [migrations], whenever the schema changes in a way that
+// older state files can't be read as.
+//
+// Versions:
+//
+//  1. Unversioned. Pages are tracked in separate maps: "synced_pages",
+//     "page_text", "page_text_hash" and "failures".
+//  2. Pages are tracked in "pages", one record per page.
+const CurrentVersion = 2
+
+// migration migrates a state file, decoded into a generic document, from
+// one version to the next in place.
+type migration func(doc map[string]any) error
+
+// migrations contains the migrations between versions, the one at index
+// i migrates version i+1 to i+2.
+var migrations = []migration{
+	migrateV1,
+}
+
+// migrate migrates the provided state document to [CurrentVersion] in
+// place. The version it was at is returned.
+func migrate(doc map[string]any) (int, error) {
+	version := 1
+	if v, ok := doc["version"]; ok {
+		n, ok := v.(int)
+		if !ok || n < 1 {
+			return 0, fmt.Errorf("invalid state version %v", v)
+		}
+		version = n
+	}
+	if version > CurrentVersion {
+		return version, fmt.Errorf("state version %d is newer than the supported version %d, upgrade remarkabledayone",
+			version, CurrentVersion)
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v-1](doc); err != nil {
+			return version, fmt.Errorf("failed to migrate state from version %d to %d: %w", v, v+1, err)
+		}
+		doc["version"] = v + 1
+	}
+	return version, nil
+}
+
+// backupStateFile copies the state file at the provided path, read as
+// the provided version, next to it. An existing backup of the same
+// version is kept, as it's the original. Returns the path of the backup.
+func backupStateFile(path string, version int) (string, error) {
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if _, err := os.Stat(backup); err == nil {
+		return backup, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	//#nosec:G304 // Why: Safe for our usecase.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return backup, os.WriteFile(backup, b, 0o600)
+}
+
+// migrateV1 merges the per-page maps of version 1 into a record per
+// page.
+func migrateV1(doc map[string]any) error {
+	pages := make(map[string]any)
+	page := func(id string) map[string]any {
+		p, ok := pages[id].(map[string]any)
+		if !ok {
+			p = make(map[string]any)
+			pages[id] = p
+		}
+		return p
+	}
+
+	for _, f := range []struct {
+		old string
+		set func(p map[string]any, v any)
+	}{
+		{"synced_pages", func(p map[string]any, _ any) { p["synced"] = true }},
+		{"page_text", func(p map[string]any, v any) { p["text"] = v }},
+		{"page_text_hash", func(p map[string]any, v any) { p["text_hash"] = v }},
+		{"failures", func(p map[string]any, v any) { p["failure"] = v }},
+	} {
+		v, ok := doc[f.old]
+		if !ok {
+			continue
+		}
+		delete(doc, f.old)
+		if v == nil {
+			continue
+		}
+
+		m, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected %q to be a map, got %T", f.old, v)
+		}
+		for id, val := range m {
+			f.set(page(id), val)
+		}
+	}
+
+	doc["pages"] = pages
+	return nil
+}
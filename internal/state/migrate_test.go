@@ -0,0 +1,173 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package state
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// v1State is a state file as written before versioning.
+const v1State = `synced_pages:
+  a: true
+  b: true
+page_text:
+  a: hello
+  c: world
+page_text_hash:
+  a: hash-a
+  c: hash-c
+failures:
+  c:
+    count: 2
+    last_error: boom
+    last_attempt: 2026-01-02T03:04:05Z
+    next_attempt: 2026-01-02T04:04:05Z
+`
+
+// writeStateFile writes contents to a state file in a temporary
+// directory, returning its path.
+func writeStateFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMigrateV1(t *testing.T) {
+	st, version, err := readStateFile(writeStateFile(t, v1State))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1", version)
+	}
+	if st.Version != CurrentVersion {
+		t.Errorf("migrated version = %d, want %d", st.Version, CurrentVersion)
+	}
+
+	next := time.Date(2026, 1, 2, 4, 4, 5, 0, time.UTC)
+	want := map[string]Page{
+		"a": {Synced: true, Text: "hello", TextHash: "hash-a"},
+		"b": {Synced: true},
+		"c": {Text: "world", TextHash: "hash-c", Failure: &PageFailure{
+			Count:       2,
+			LastError:   "boom",
+			LastAttempt: next.Add(-time.Hour),
+			NextAttempt: next,
+		}},
+	}
+	if len(st.Pages) != len(want) {
+		t.Fatalf("got %d pages, want %d: %+v", len(st.Pages), len(want), st.Pages)
+	}
+	for id, w := range want {
+		got, ok := st.Pages[id]
+		if !ok {
+			t.Errorf("page %s is missing", id)
+			continue
+		}
+		if got.Synced != w.Synced || got.Text != w.Text || got.TextHash != w.TextHash {
+			t.Errorf("page %s = %+v, want %+v", id, got, w)
+		}
+		switch {
+		case (got.Failure == nil) != (w.Failure == nil):
+			t.Errorf("page %s failure = %+v, want %+v", id, got.Failure, w.Failure)
+		case w.Failure != nil && (got.Failure.Count != w.Failure.Count ||
+			got.Failure.LastError != w.Failure.LastError ||
+			!got.Failure.LastAttempt.Equal(w.Failure.LastAttempt) ||
+			!got.Failure.NextAttempt.Equal(w.Failure.NextAttempt)):
+			t.Errorf("page %s failure = %+v, want %+v", id, got.Failure, w.Failure)
+		}
+	}
+}
+
+func TestMigrateRejectsVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version any
+		wantErr string
+	}{
+		{"newer", CurrentVersion + 1, "newer than the supported version"},
+		{"string", "2", "invalid state version"},
+		{"float", 1.5, "invalid state version"},
+		{"zero", 0, "invalid state version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := map[string]any{"version": tt.version, "pages": map[string]any{}}
+			_, err := migrate(doc)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("migrate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrateBackup(t *testing.T) {
+	path := writeStateFile(t, v1State)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	f, err := OpenFile(log, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck // Why: Best effort.
+
+	backup, err := os.ReadFile(path + ".v1.bak")
+	if err != nil {
+		t.Fatalf("backup wasn't written: %v", err)
+	}
+	if string(backup) != v1State {
+		t.Errorf("backup = %q, want the original file", backup)
+	}
+
+	// The original is only replaced once the state is saved.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != v1State {
+		t.Errorf("state file was modified before saving: %q", b)
+	}
+
+	if err := f.UpdatePage("d", nil, func(p *Page) { p.Synced = true }); err != nil {
+		t.Fatal(err)
+	}
+	st, version, err := readStateFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != CurrentVersion {
+		t.Errorf("saved version = %d, want %d", version, CurrentVersion)
+	}
+	if len(st.Pages) != 4 {
+		t.Errorf("saved %d pages, want 4", len(st.Pages))
+	}
+
+	// The backup is the original, it isn't replaced by later migrations.
+	if b, err := os.ReadFile(path + ".v1.bak"); err != nil || string(b) != v1State {
+		t.Errorf("backup changed after saving: %q, %v", b, err)
+	}
+}
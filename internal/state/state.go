@@ -20,61 +20,220 @@
 package state
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
+)
+
+// Contains the supported storage backends of the state.
+const (
+	// BackendYAML stores the state in a YAML file, see [File].
+	BackendYAML = "yaml"
 
-	"gopkg.in/yaml.v3"
+	// BackendBolt stores the state in an embedded database, see [Bolt].
+	BackendBolt = "bolt"
 )
 
 // FileName is the name where state is stored.
 const FileName = "state.yml"
 
-// State represents the state object used for tracking state across runs.
+// DBFileName is the name where state is stored by [BackendBolt].
+const DBFileName = "state.db"
+
+// ErrReadOnly is returned when modifying a store opened as read-only.
+var ErrReadOnly = errors.New("state is opened as read-only")
+
+// Store stores the state. Implementations are safe for concurrent use
+// and return copies, modifying returned values doesn't change the store.
+type Store interface {
+	// Path returns where the state is stored. Empty if it isn't saved.
+	Path() string
+
+	// Pages returns a map of page IDs to every known page.
+	Pages() (map[string]*Page, error)
+
+	// Page returns the page with the provided ID. Nil if it's unknown.
+	Page(id string) (*Page, error)
+
+	// UpdatePage calls fn with the page with the provided ID, added if
+	// it's unknown, and commits the changes to it. If attempt is not nil,
+	// it's recorded in the same transaction.
+	UpdatePage(id string, attempt *Attempt, fn func(p *Page)) error
+
+	// DeletePages removes the pages with the provided IDs.
+	DeletePages(ids ...string) error
+
+	// Attempts returns the recorded attempts to sync the page with the
+	// provided ID, oldest first. Not every store keeps them.
+	Attempts(id string) ([]*Attempt, error)
+
+	// Documents returns a map of document IDs to every synced document.
+	Documents() (map[string]*Document, error)
+
+	// PutDocument stores the document with the provided ID.
+	PutDocument(id string, d *Document) error
+
+	// DeleteDocuments removes the documents with the provided IDs. Their
+	// pages are kept.
+	DeleteDocuments(ids ...string) error
+
+	// Export returns the pages and documents in the store.
+	Export() (*State, error)
+
+	// Import adds the pages and documents in st to the store in a single
+	// transaction, replacing those with the same IDs.
+	Import(st *State) error
+
+	// Close closes the store, releasing its lock.
+	Close() error
+}
+
+// State is the state as a whole, as stored in the state file.
 type State struct {
-	// log is the logger for the state.
-	log *slog.Logger `yaml:"-"`
+	// Version is the version of the schema of the state, see
+	// [CurrentVersion].
+	Version int `yaml:"version" json:"version"`
 
-	// path is the location where this state file was loaded from. If not
-	// set, it shouldn't be saved.
-	path string `yaml:"-"`
+	// Pages is a map of page IDs to what's known about them.
+	Pages map[string]*Page `yaml:"pages" json:"pages"`
 
-	// SyncedPages is a map of synced page IDs.
-	SyncedPages map[string]struct{} `yaml:"synced_pages"`
+	// Documents is a map of document IDs to the documents that have been
+	// synced, as of their last sync.
+	Documents map[string]*Document `yaml:"documents,omitempty" json:"documents"`
 }
 
-// readStateFile reads the state file at the given path and returns the
-// state. If an error occurs, it will return the error.
-func readStateFile(path string) (*State, error) {
-	//#nosec:G304 // Why: Safe for our usecase.
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+// New creates a new, empty state.
+func New() *State {
+	return &State{
+		Version:   CurrentVersion,
+		Pages:     make(map[string]*Page),
+		Documents: make(map[string]*Document),
 	}
-	defer f.Close() //nolint:errcheck // Why: Best effort.
+}
+
+// Page tracks a single page.
+type Page struct {
+	// Synced is true once the page has been synced.
+	Synced bool `yaml:"synced,omitempty" json:"synced,omitempty"`
+
+	// SyncedAt is when the page was synced. Zero for pages synced before
+	// it was recorded.
+	SyncedAt time.Time `yaml:"synced_at,omitempty" json:"synced_at"`
+
+	// EntryID is the ID of the journal entry created for the page, if
+	// known.
+	EntryID string `yaml:"entry_id,omitempty" json:"entry_id,omitempty"`
+
+	// Text is the text recognized on the page.
+	Text string `yaml:"text,omitempty" json:"text,omitempty"`
+
+	// TextHash is the hash of the page contents at the time Text was
+	// recognized.
+	TextHash string `yaml:"text_hash,omitempty" json:"text_hash,omitempty"`
+
+	// Failure tracks the failures syncing the page. Nil once it's
+	// synced.
+	Failure *PageFailure `yaml:"failure,omitempty" json:"failure,omitempty"`
+
+	// Hash is the hash of the page's contents when it was last seen, so
+	// that unchanged pages that have been synced aren't downloaded again.
+	Hash string `yaml:"hash,omitempty" json:"hash,omitempty"`
+
+	// Document is the ID of the document the page was last seen in.
+	Document string `yaml:"document,omitempty" json:"document,omitempty"`
+
+	// DeletedAt is when the page was no longer found in its document. The
+	// page is kept until it's pruned, so that it isn't synced again if it
+	// comes back, e.g., after being moved to another document and back.
+	// Zero if the page exists.
+	DeletedAt time.Time `yaml:"deleted_at,omitempty" json:"deleted_at"`
+}
 
-	st := &State{}
-	if err := yaml.NewDecoder(f).Decode(st); err != nil {
-		return nil, err
+// clone returns a copy of the page.
+func (p *Page) clone() *Page {
+	c := *p
+	if p.Failure != nil {
+		f := *p.Failure
+		c.Failure = &f
 	}
+	return &c
+}
+
+// Document tracks a synced document.
+type Document struct {
+	// Name is the name of the document.
+	Name string `yaml:"name" json:"name"`
+
+	// Path is the path of the document, e.g., "Journals/Daily".
+	Path string `yaml:"path" json:"path"`
+
+	// Pages is the IDs of the pages in the document.
+	Pages []string `yaml:"pages" json:"pages"`
+
+	// Pending is the number of pages that weren't synced yet.
+	Pending int `yaml:"pending" json:"pending"`
+
+	// LastSync is when the document was last synced.
+	LastSync time.Time `yaml:"last_sync" json:"last_sync"`
+}
+
+// clone returns a copy of the document.
+func (d *Document) clone() *Document {
+	c := *d
+	c.Pages = append([]string(nil), d.Pages...)
+	return &c
+}
+
+// PageFailure tracks the failed attempts to sync a page.
+type PageFailure struct {
+	// Count is the number of consecutive failed attempts.
+	Count int `yaml:"count" json:"count"`
+
+	// LastError is the error of the last attempt.
+	LastError string `yaml:"last_error" json:"last_error"`
+
+	// LastAttempt is when the page was last attempted.
+	LastAttempt time.Time `yaml:"last_attempt" json:"last_attempt"`
+
+	// NextAttempt is when the page should be attempted again.
+	NextAttempt time.Time `yaml:"next_attempt" json:"next_attempt"`
+}
 
-	return st, nil
+// Quarantined returns true if the page has failed at least maxFailures
+// times and shouldn't be attempted again until it's retried manually.
+// Pages are never quarantined if maxFailures is zero.
+func (f *PageFailure) Quarantined(maxFailures int) bool {
+	return maxFailures > 0 && f.Count >= maxFailures
 }
 
-// Load loads the state from disk. If an error occurs, it will return
-// a new state.
-func Load(log *slog.Logger) *State {
-	var defaultState = &State{log: log}
+// Attempt is an attempt to sync a page.
+type Attempt struct {
+	// Time is when the page was attempted.
+	Time time.Time `json:"time"`
 
+	// Error is why the attempt failed. Empty if it succeeded.
+	Error string `json:"error,omitempty"`
+
+	// EntryID is the ID of the journal entry created by the attempt, if
+	// known.
+	EntryID string `json:"entry_id,omitempty"`
+}
+
+// Locate returns the path of the state file with the provided name. The
+// first one that exists in the search directories is used, otherwise the
+// path a new one should be created at. Empty if no location could be
+// determined. The current working directory isn't searched, so that a
+// stray state file is never picked up by accident.
+func Locate(log *slog.Logger, name string) string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		log.With("error", err).Error("failed to get user home directory")
-		return &State{}
+		return ""
 	}
 
-	// nolint:errcheck // Why: Best effort to get the current working directory.
-	cwd, _ := os.Getwd()
-
 	// Load the state file from disk.
 	var searchDirs = []string{
 		// Use the XDG_STATE_HOME environment variable if it's set.
@@ -86,58 +245,60 @@ func Load(log *slog.Logger) *State {
 			return filepath.Join(stHome, "remarkabledayone")
 		}(),
 		filepath.Join(homeDir, ".local", "state", "remarkabledayone"),
-		cwd,
 	}
 
-	// Attempt each search directory.
+	// Attempt each search directory, defaulting to the first non-empty
+	// one if none of them contain a state file.
+	var defaultPath string
 	for _, dir := range searchDirs {
 		if dir == "" {
 			continue
 		}
 
-		// Save the first non-empty directory for saving if we created a new
-		// state later.
-		path := filepath.Join(dir, FileName)
-		if defaultState.path == "" {
-			defaultState.path = path
+		path := filepath.Join(dir, name)
+		if defaultPath == "" {
+			defaultPath = path
 		}
 
-		// Failed to read or doesn't exist, continue.
-		st, err := readStateFile(path)
-		if err != nil {
-			continue
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			return path
 		}
-
-		st.log = defaultState.log
-		st.path = defaultState.path
-
-		return st
 	}
 
-	// Didn't find the state file, return a new state.
-	return defaultState
+	return defaultPath
 }
 
-// Save saves the state to disk if there was a path provided when it was
-// created. Top-level directories are created if they don't exist.
-func (s *State) Save() error {
-	if s.path == "" {
-		s.log.Warn("not saving state, no path provided")
-		return nil
+// DefaultFile returns the name of the file the provided backend stores
+// the state in by default.
+func DefaultFile(backend string) (string, error) {
+	switch backend {
+	case BackendYAML, "":
+		return FileName, nil
+	case BackendBolt:
+		return DBFileName, nil
+	default:
+		return "", fmt.Errorf("unknown state backend %q", backend)
 	}
+}
 
-	s.log.Info("saving state", "path", s.path)
-
-	// Ensure the directory exists.
-	if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
-		return err
+// Open opens the state stored by the provided backend at the provided
+// path, found with [Locate] if empty. Unless opened as read-only, the
+// state is locked until it's closed, see [Lock].
+func Open(log *slog.Logger, backend, path string, readOnly bool) (Store, error) {
+	if path == "" {
+		name, err := DefaultFile(backend)
+		if err != nil {
+			return nil, err
+		}
+		path = Locate(log, name)
 	}
 
-	f, err := os.Create(s.path)
-	if err != nil {
-		return err
+	switch backend {
+	case BackendYAML, "":
+		return OpenFile(log, path, readOnly)
+	case BackendBolt:
+		return OpenBolt(log, path, readOnly)
+	default:
+		return nil, fmt.Errorf("unknown state backend %q", backend)
 	}
-	defer f.Close() //nolint:errcheck // Why: Best effort.
-
-	return yaml.NewEncoder(f).Encode(s)
 }
@@ -0,0 +1,122 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+)
+
+// Diff is the difference between the state and a document as it
+// currently is on the source, i.e., what the next sync would change.
+type Diff struct {
+	// Document is the path of the document.
+	Document string `json:"document"`
+
+	// Synced is the pages that have already been synced.
+	Synced []PageResult `json:"synced"`
+
+	// Pending is the pages that weren't synced yet. Reason is set for
+	// pages that are failing.
+	Pending []PageResult `json:"pending"`
+
+	// Moved is the pages that were last seen in another document. Reason
+	// is the path of that document.
+	Moved []PageResult `json:"moved"`
+
+	// Restored is the pages that were marked as deleted, but are in the
+	// document again.
+	Restored []PageResult `json:"restored"`
+
+	// Removed is the IDs of the pages that were in the document at its
+	// last sync, but no longer are.
+	Removed []string `json:"removed"`
+}
+
+// Diff compares the state with the document with the provided name or
+// path, DOCUMENT_NAME if empty, as it currently is on the source. Nothing
+// is changed.
+func (s *Syncer) Diff(ctx context.Context, name string) (*Diff, error) {
+	if name == "" {
+		name = s.cfg.DocumentName
+	}
+
+	info, doc, err := s.fetchDocument(ctx, name, false)
+	if err != nil {
+		return nil, err
+	}
+	defer doc.Close() //nolint:errcheck // Why: Best effort.
+
+	known, err := s.state.Pages()
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.state.Documents()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diff{
+		Document: info.Path,
+		Synced:   []PageResult{},
+		Pending:  []PageResult{},
+		Moved:    []PageResult{},
+		Restored: []PageResult{},
+		Removed:  []string{},
+	}
+	current := make(map[string]struct{}, len(doc.Zip.Pages))
+	for i := range doc.Zip.Pages {
+		p := &doc.Zip.Pages[i]
+		current[p.ID] = struct{}{}
+
+		sp, ok := known[p.ID]
+		switch {
+		case !ok:
+			d.Pending = append(d.Pending, newPageResult(p, ""))
+			continue
+		case !sp.Synced:
+			reason := ""
+			if sp.Failure != nil {
+				reason = fmt.Sprintf("failed %d times: %s", sp.Failure.Count, sp.Failure.LastError)
+			}
+			d.Pending = append(d.Pending, newPageResult(p, reason))
+		default:
+			d.Synced = append(d.Synced, newPageResult(p, ""))
+		}
+
+		if sp.Document != "" && sp.Document != info.ID {
+			from := sp.Document
+			if prev, ok := docs[from]; ok {
+				from = prev.Path
+			}
+			d.Moved = append(d.Moved, newPageResult(p, from))
+		}
+		if !sp.DeletedAt.IsZero() {
+			d.Restored = append(d.Restored, newPageResult(p, ""))
+		}
+	}
+
+	if prev, ok := docs[info.ID]; ok {
+		for _, id := range prev.Pages {
+			if _, exists := current[id]; !exists {
+				d.Removed = append(d.Removed, id)
+			}
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,221 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+	"github.com/jaredallard/remarkabledayone/internal/state"
+)
+
+// cleanup records the pages of the synced document in the state, marks
+// pages that are no longer in it as deleted and prunes pages that were
+// deleted long enough ago. Must only be called after a successful sync,
+// so that pages are never considered deleted because of a partial sync.
+//
+// Pages are tracked by ID across documents, a page that moved to another
+// synced document is recorded as being in that one instead. Only pages
+// last seen in this document are marked as deleted, so that syncing one
+// document doesn't affect the pages of another. State written before
+// documents were recorded only contains the pages of a single document,
+// so in that case every page that isn't in this document is marked as
+// deleted.
+func (s *Syncer) cleanup(info *rm.DocumentInfo, pages []rm.Page) error {
+	known, err := s.state.Pages()
+	if err != nil {
+		return err
+	}
+	docs, err := s.state.Documents()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	changed := state.New()
+	ids := make([]string, 0, len(pages))
+	current := make(map[string]struct{}, len(pages))
+	pending := 0
+	for _, p := range pages {
+		ids = append(ids, p.ID)
+		current[p.ID] = struct{}{}
+
+		sp, ok := known[p.ID]
+		if !ok || !sp.Synced {
+			pending++
+		}
+		if !ok {
+			continue
+		}
+		// Pages that weren't downloaded are unchanged.
+		changedHash := p.Hash != "" && p.Hash != sp.Hash
+		if sp.Document == info.ID && sp.DeletedAt.IsZero() && !changedHash {
+			continue
+		}
+
+		log := s.log.With("page", p.ID)
+		switch {
+		case sp.Document != "" && sp.Document != info.ID:
+			from := sp.Document
+			if d, ok := docs[from]; ok {
+				from = d.Path
+			}
+			log.Info("page moved from another document", "from", from, "to", info.Path)
+		case !sp.DeletedAt.IsZero():
+			log.Info("deleted page is back", "deleted_at", sp.DeletedAt)
+		}
+		sp.Document = info.ID
+		sp.DeletedAt = time.Time{}
+		if p.Hash != "" {
+			sp.Hash = p.Hash
+		}
+		changed.Pages[p.ID] = sp
+	}
+
+	// Pages that were in this document before, or every page for state
+	// from before documents were recorded.
+	var previous []string
+	if prev, ok := docs[info.ID]; ok {
+		previous = prev.Pages
+	} else if len(docs) == 0 {
+		for id := range known {
+			previous = append(previous, id)
+		}
+	}
+	for _, id := range previous {
+		sp, ok := known[id]
+		if _, exists := current[id]; exists || !ok || !sp.DeletedAt.IsZero() {
+			continue
+		}
+		// Already seen in the document it moved to.
+		if sp.Document != "" && sp.Document != info.ID {
+			continue
+		}
+
+		s.log.With("page", id).Info("page no longer exists, marking it as deleted")
+		sp.Document = info.ID
+		sp.DeletedAt = now
+		changed.Pages[id] = sp
+	}
+
+	changed.Documents[info.ID] = &state.Document{
+		Name:     info.Name,
+		Path:     info.Path,
+		Pages:    ids,
+		Pending:  pending,
+		LastSync: now,
+	}
+	if err := s.state.Import(changed); err != nil {
+		return err
+	}
+
+	return s.prune(known, now)
+}
+
+// prune removes pages that were deleted longer ago than the configured
+// TTL from the state.
+func (s *Syncer) prune(known map[string]*state.Page, now time.Time) error {
+	if s.cfg.TombstoneTTL <= 0 {
+		return nil
+	}
+
+	var ids []string
+	for id, p := range known {
+		if !p.DeletedAt.IsZero() && now.Sub(p.DeletedAt) > s.cfg.TombstoneTTL {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	s.log.Info("pruning deleted pages from state", "pages", len(ids))
+	if err := s.state.DeletePages(ids...); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if path, ok := s.previewPath(id); ok {
+			os.Remove(path) //nolint:errcheck // Why: Best effort.
+		}
+	}
+	return nil
+}
+
+// DocumentStatus is the sync status of a document, as of its last sync.
+type DocumentStatus struct {
+	// ID is the ID of the document.
+	ID string `json:"id"`
+
+	// Name is the name of the document.
+	Name string `json:"name"`
+
+	// Path is the path of the document, e.g., "Journals/Daily".
+	Path string `json:"path"`
+
+	// Pages is the number of pages in the document.
+	Pages int `json:"pages"`
+
+	// Pending is the number of pages that weren't synced yet, including
+	// failing ones.
+	Pending int `json:"pending"`
+
+	// Failing is the number of pages that failed to sync.
+	Failing int `json:"failing"`
+
+	// LastSync is when the document was last synced.
+	LastSync time.Time `json:"last_sync"`
+}
+
+// documentStatus returns the status of every synced document, sorted by
+// path.
+func (s *Syncer) documentStatus() ([]DocumentStatus, error) {
+	known, err := s.state.Pages()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.state.Documents()
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]DocumentStatus, 0, len(stored))
+	for id, d := range stored {
+		failing := 0
+		for _, p := range d.Pages {
+			if page, ok := known[p]; ok && page.Failure != nil {
+				failing++
+			}
+		}
+		docs = append(docs, DocumentStatus{
+			ID:       id,
+			Name:     d.Name,
+			Path:     d.Path,
+			Pages:    len(d.Pages),
+			Pending:  d.Pending,
+			Failing:  failing,
+			LastSync: d.LastSync,
+		})
+	}
+	slices.SortFunc(docs, func(a, b DocumentStatus) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return docs, nil
+}
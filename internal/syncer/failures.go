@@ -0,0 +1,84 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/state"
+)
+
+// maxBackoff is the longest a failing page waits between attempts.
+const maxBackoff = 7 * 24 * time.Hour
+
+// backoff returns how long to wait before attempting a page again after
+// the provided number of consecutive failures. The wait doubles with
+// every failure, starting at base.
+func backoff(base time.Duration, failures int) time.Duration {
+	d := base
+	for i := 1; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// recordFailure records a failed attempt to sync a page. Returns true if
+// the page was quarantined because of it.
+func (s *Syncer) recordFailure(id string, err error) bool {
+	now := time.Now()
+	var f state.PageFailure
+	if serr := s.state.UpdatePage(id, &state.Attempt{Time: now, Error: err.Error()}, func(p *state.Page) {
+		if p.Failure == nil {
+			p.Failure = &state.PageFailure{}
+		}
+		p.Failure.Count++
+		p.Failure.LastError = err.Error()
+		p.Failure.LastAttempt = now
+		p.Failure.NextAttempt = now.Add(backoff(s.cfg.RetryBackoff, p.Failure.Count))
+		f = *p.Failure
+	}); serr != nil {
+		s.log.With("page", id, "error", serr).Warn("failed to record page failure")
+		return false
+	}
+
+	if !f.Quarantined(s.cfg.MaxPageFailures) {
+		return false
+	}
+	s.log.With("page", id, "failures", f.Count).
+		Warn("page quarantined, run \"remarkabledayone retry\" to try it again")
+	return true
+}
+
+// holdReason returns why a page shouldn't be attempted yet because of
+// previous failures. Empty if it should be attempted. p may be nil for
+// unknown pages.
+func (s *Syncer) holdReason(p *state.Page, now time.Time) string {
+	if p == nil || p.Failure == nil {
+		return ""
+	}
+	f := p.Failure
+
+	if f.Quarantined(s.cfg.MaxPageFailures) {
+		return fmt.Sprintf("quarantined after %d failures", f.Count)
+	}
+	if now.Before(f.NextAttempt) {
+		return fmt.Sprintf("backing off after %d failures until %s", f.Count, f.NextAttempt.Format(time.DateTime))
+	}
+	return ""
+}
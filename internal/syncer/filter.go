@@ -0,0 +1,137 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+)
+
+// PageRange is an inclusive range of one-based page numbers. A zero
+// Start or End leaves that side of the range open.
+type PageRange struct {
+	Start int
+	End   int
+}
+
+// contains returns true if the one-based page number is in the range.
+func (r PageRange) contains(n int) bool {
+	return (r.Start == 0 || n >= r.Start) && (r.End == 0 || n <= r.End)
+}
+
+// ParsePageRanges parses a comma-separated list of page numbers and
+// ranges, e.g., "1-10,15,120-". Pages are numbered from one, like on the
+// device.
+func ParsePageRanges(s string) ([]PageRange, error) {
+	var ranges []PageRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		start, end, isRange := strings.Cut(part, "-")
+		var r PageRange
+		var err error
+		if start != "" {
+			if r.Start, err = strconv.Atoi(strings.TrimSpace(start)); err != nil || r.Start < 1 {
+				return nil, fmt.Errorf("invalid page number %q", start)
+			}
+		}
+		if !isRange {
+			r.End = r.Start
+		} else if end != "" {
+			if r.End, err = strconv.Atoi(strings.TrimSpace(end)); err != nil || r.End < 1 {
+				return nil, fmt.Errorf("invalid page number %q", end)
+			}
+		}
+		if r.End != 0 && r.Start > r.End {
+			return nil, fmt.Errorf("invalid page range %q, start is after end", part)
+		}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, nil
+}
+
+// Filter selects the pages of a document to sync. The zero value selects
+// every page. All of the set fields must match for a page to be
+// selected.
+type Filter struct {
+	// Pages is a list of page ranges. A page must be in at least one of
+	// them.
+	Pages []PageRange
+
+	// Since only selects pages modified at or after this time.
+	Since time.Time
+
+	// Until only selects pages modified before this time.
+	Until time.Time
+
+	// Last only selects the last N pages of the document.
+	Last int
+}
+
+// Apply returns the pages that match the filter, keeping their order.
+func (f *Filter) Apply(pages []rm.Page) []rm.Page {
+	if f == nil {
+		return pages
+	}
+
+	// The last page is based on the index, not on the number of pages
+	// with content.
+	lastIndex := -1
+	for i := range pages {
+		lastIndex = max(lastIndex, pages[i].Index)
+	}
+
+	out := make([]rm.Page, 0, len(pages))
+	for i := range pages {
+		p := &pages[i]
+		if f.Last > 0 && p.Index <= lastIndex-f.Last {
+			continue
+		}
+		if !f.Since.IsZero() && p.Modified.Before(f.Since) {
+			continue
+		}
+		if !f.Until.IsZero() && !p.Modified.Before(f.Until) {
+			continue
+		}
+		if len(f.Pages) > 0 && !f.inRanges(p.Index+1) {
+			continue
+		}
+		out = append(out, *p)
+	}
+
+	return out
+}
+
+// inRanges returns true if the one-based page number is in any of the
+// filter's page ranges.
+func (f *Filter) inRanges(n int) bool {
+	for _, r := range f.Pages {
+		if r.contains(n) {
+			return true
+		}
+	}
+	return false
+}
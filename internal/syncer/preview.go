@@ -0,0 +1,97 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+)
+
+// ErrNoPreview is returned by [Syncer.Preview] when there's no preview
+// of a page.
+var ErrNoPreview = errors.New("no preview of page")
+
+// previewPath returns the path of the preview of the page with the
+// provided ID. Returns false if previews aren't kept or the ID isn't a
+// valid page ID.
+func (s *Syncer) previewPath(id string) (string, bool) {
+	if s.previewDir == "" || id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", false
+	}
+	return filepath.Join(s.previewDir, id+".png"), true
+}
+
+// Preview returns the path of a PNG of the page with the provided ID, as
+// it was last rendered. Only pages rendered while previews are kept,
+// i.e., while the API is enabled, have one. Returns [ErrNoPreview] if
+// there's no preview of the page.
+func (s *Syncer) Preview(id string) (string, error) {
+	path, ok := s.previewPath(id)
+	if !ok {
+		return "", ErrNoPreview
+	}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrNoPreview
+		}
+		return "", err
+	}
+	return path, nil
+}
+
+// savePreview keeps the first rendered image of a page as its preview.
+func (s *Syncer) savePreview(page *rm.Page) error {
+	path, ok := s.previewPath(page.ID)
+	if !ok || len(page.PNGPaths) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.previewDir, 0o750); err != nil {
+		return err
+	}
+
+	//#nosec:G304 // Why: Safe for our usecase.
+	src, err := os.Open(page.PNGPaths[0])
+	if err != nil {
+		return err
+	}
+	defer src.Close() //nolint:errcheck // Why: Best effort.
+
+	// Written to a temporary file first so that a preview being served is
+	// never partially written.
+	tmp, err := os.CreateTemp(s.previewDir, page.ID+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // Why: Best effort.
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close() //nolint:errcheck // Why: Best effort.
+		return fmt.Errorf("failed to write preview: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
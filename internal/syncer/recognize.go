@@ -0,0 +1,120 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/ocr"
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+	"github.com/jaredallard/remarkabledayone/internal/state"
+)
+
+// newRecognizer creates the configured recognition provider, caching
+// its results in the provided state. Nil is returned if OCR is disabled.
+func newRecognizer(cfg *config.Config, st state.Store) (ocr.Provider, error) {
+	if !cfg.OCR {
+		return nil, nil
+	}
+
+	var p ocr.Provider
+	var err error
+	switch cfg.OCRProvider {
+	case "tesseract":
+		p, err = ocr.NewTesseract(cfg.OCRLanguage, cfg.OCRMinConfidence)
+	case "http":
+		p, err = ocr.NewHTTP(cfg.OCRURL, cfg.OCRToken, ocr.HTTPInput(cfg.OCRInput), cfg.OCRLanguage, cfg.OCRTimeout)
+	default:
+		err = fmt.Errorf("unknown OCR provider %q", cfg.OCRProvider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ocr.WithCache(p, &stateCache{st: st}, recognizerOptions(cfg)), nil
+}
+
+// recognizerOptions returns the options that change the text recognized
+// on a page, so that changing them recognizes pages again.
+func recognizerOptions(cfg *config.Config) string {
+	exclude := ""
+	if cfg.ExcludeLayers != nil {
+		exclude = cfg.ExcludeLayers.String()
+	}
+
+	opts := []string{
+		"provider=" + cfg.OCRProvider,
+		"language=" + cfg.OCRLanguage,
+		"visible_layers_only=" + strconv.FormatBool(cfg.VisibleLayersOnly),
+		"exclude_layers=" + exclude,
+		"separate_layers=" + strconv.FormatBool(cfg.SeparateLayers),
+	}
+	switch cfg.OCRProvider {
+	case "tesseract":
+		opts = append(opts, "min_confidence="+strconv.FormatFloat(cfg.OCRMinConfidence, 'g', -1, 64))
+	case "http":
+		opts = append(opts, "url="+cfg.OCRURL, "input="+cfg.OCRInput)
+	}
+	return strings.Join(opts, "\n")
+}
+
+// stateCache is an [ocr.Cache] that stores recognized text in the state.
+// Safe for concurrent use, pages are recognized in parallel.
+type stateCache struct {
+	st state.Store
+}
+
+// Get implements [ocr.Cache].
+func (c *stateCache) Get(pageID, hash string) (string, bool) {
+	p, err := c.st.Page(pageID)
+	if err != nil || p == nil || p.TextHash == "" || p.TextHash != hash {
+		return "", false
+	}
+	return p.Text, true
+}
+
+// Put implements [ocr.Cache]. Failing to store the text only means it's
+// recognized again next time.
+func (c *stateCache) Put(pageID, hash, text string) {
+	c.st.UpdatePage(pageID, nil, func(p *state.Page) { //nolint:errcheck // Why: Best effort.
+		p.Text, p.TextHash = text, hash
+	})
+}
+
+// recognize returns the handwriting recognized on the provided page.
+// Recognition is best effort, failures are logged and an empty string is
+// returned.
+func (s *Syncer) recognize(ctx context.Context, page *rm.Page) string {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.OCRTimeout)
+	defer cancel()
+
+	text, err := s.ocr.Recognize(ctx, &ocr.Input{
+		PageID:   page.ID,
+		RMPath:   page.Path,
+		PNGPaths: page.PNGPaths,
+	})
+	if err != nil {
+		s.log.With("page", page.ID, "error", err).Warn("failed to recognize handwriting")
+		return ""
+	}
+	return text
+}
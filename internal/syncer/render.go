@@ -0,0 +1,147 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/metrics"
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+)
+
+// renderedPage is a page that has been prepared for an entry.
+type renderedPage struct {
+	// page is the page, with PNGPaths set if it was rendered.
+	page *rm.Page
+
+	// body is the body of the entry.
+	body string
+
+	// skip is why the page shouldn't have an entry created for it, if it
+	// shouldn't. Skipped pages aren't marked as synced.
+	skip string
+
+	// err is set if the page failed to render.
+	err error
+}
+
+// renderPages renders the provided pages using the configured number of
+// workers. A channel is returned for every page, in the same order, that
+// receives the page once it's been rendered. This allows entries to be
+// created in order while later pages are still rendering.
+//
+// Once the context is cancelled, the remaining pages fail immediately.
+// The returned function waits for all workers to exit.
+func (s *Syncer) renderPages(ctx context.Context, pages []rm.Page) ([]<-chan *renderedPage, func()) {
+	results := make([]chan *renderedPage, len(pages))
+	out := make([]<-chan *renderedPage, len(pages))
+	for i := range pages {
+		results[i] = make(chan *renderedPage, 1)
+		out[i] = results[i]
+	}
+
+	jobs := make(chan int)
+	go func() {
+		for i := range pages {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	workers := max(s.cfg.RenderWorkers, 1)
+	var wg sync.WaitGroup
+	for range min(workers, len(pages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] <- s.renderPage(ctx, &pages[i])
+			}
+		}()
+	}
+
+	return out, wg.Wait
+}
+
+// renderPage prepares a single page for an entry: extracts its text,
+// skips it if it's blank, renders it and recognizes its handwriting.
+// Rendering is limited to the configured page timeout.
+func (s *Syncer) renderPage(ctx context.Context, page *rm.Page) *renderedPage {
+	start := time.Now()
+	r := &renderedPage{page: page}
+	if r.err = ctx.Err(); r.err != nil {
+		return r
+	}
+	if page.Path == "" {
+		r.err = errors.New("page contents weren't downloaded")
+		return r
+	}
+
+	if s.cfg.PageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.PageTimeout)
+		defer cancel()
+	}
+
+	// Extract any typed text and highlights to use as the body of the
+	// entry. This is best effort, the rendered page is still useful
+	// without it.
+	if scene, err := page.Scene(); err != nil {
+		s.log.With("page", page.ID, "error", err).Warn("failed to read page contents")
+	} else {
+		// Blank pages aren't marked as synced so that they're picked up
+		// once they've been written on.
+		if s.cfg.SkipBlankPages && scene.IsBlank(s.cfg.BlankPageThreshold) {
+			r.skip = fmt.Sprintf("page is blank (%d strokes, %.4f coverage)", scene.Strokes, scene.InkCoverage)
+			return r
+		}
+		r.body = scene.Markdown()
+	}
+
+	// Render the page to a PNG.
+	if err := page.Render(ctx, &rm.RenderOptions{
+		VisibleLayersOnly: s.cfg.VisibleLayersOnly,
+		ExcludeLayers:     s.cfg.ExcludeLayers,
+		PerLayer:          s.cfg.SeparateLayers,
+	}); err != nil {
+		r.err = fmt.Errorf("failed to render page: %w", err)
+		return r
+	}
+	if len(page.PNGPaths) == 0 {
+		// Not marked as synced so that it's picked up if content is
+		// later added to a rendered layer.
+		r.skip = "no layers to render"
+		return r
+	}
+
+	if err := s.savePreview(page); err != nil {
+		s.log.With("page", page.ID, "error", err).Warn("failed to save preview")
+	}
+
+	if s.ocr != nil {
+		r.body = joinBody(r.body, s.recognize(ctx, page))
+	}
+
+	metrics.RenderDuration.Observe(time.Since(start).Seconds())
+	s.log.With("page", page.ID, "index", page.Index+1, "duration", time.Since(start)).Debug("rendered page")
+	return r
+}
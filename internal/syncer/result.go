@@ -0,0 +1,128 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jaredallard/remarkabledayone/internal/metrics"
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+)
+
+// PageResult is the outcome of syncing a single page.
+type PageResult struct {
+	// ID is the ID of the page.
+	ID string `json:"id"`
+
+	// Page is the one-based page number, as shown on the device.
+	Page int `json:"page"`
+
+	// Reason is why the page was skipped or failed. Empty for pages that
+	// were synced.
+	Reason string `json:"reason,omitempty"`
+}
+
+// newPageResult creates a [PageResult] for the provided page.
+func newPageResult(p *rm.Page, reason string) PageResult {
+	return PageResult{ID: p.ID, Page: p.Index + 1, Reason: reason}
+}
+
+// Result is the outcome of [Syncer.Sync]. Pages that were already
+// synced, or weren't selected, aren't included.
+type Result struct {
+	// Document is the name of the synced document.
+	Document string `json:"document"`
+
+	// Created is the pages an entry was created for.
+	Created []PageResult `json:"created"`
+
+	// Updated is the pages that were recorded as synced without creating
+	// an entry, see [Options.MarkSynced].
+	Updated []PageResult `json:"updated"`
+
+	// Skipped is the pages that weren't synced this time, e.g., because
+	// they're blank or backing off after failures.
+	Skipped []PageResult `json:"skipped"`
+
+	// Failed is the pages that failed to sync.
+	Failed []PageResult `json:"failed"`
+
+	// Quarantined is the failed pages that were quarantined by this sync,
+	// see [config.Config.MaxPageFailures].
+	Quarantined []PageResult `json:"quarantined"`
+
+	// Duration is how long the sync took.
+	Duration time.Duration `json:"-"`
+
+	// Error is the error the sync failed with, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// NewResult creates an empty [Result]. Lists are never nil so that they
+// are encoded as empty JSON arrays.
+func NewResult(document string) *Result {
+	return &Result{
+		Document:    document,
+		Created:     []PageResult{},
+		Updated:     []PageResult{},
+		Skipped:     []PageResult{},
+		Failed:      []PageResult{},
+		Quarantined: []PageResult{},
+	}
+}
+
+// PartialFailure returns true if some, but not all, of the attempted
+// pages failed.
+func (r *Result) PartialFailure() bool {
+	return len(r.Failed) > 0 && len(r.Created)+len(r.Updated) > 0
+}
+
+// TotalFailure returns true if pages were attempted and every one of
+// them failed.
+func (r *Result) TotalFailure() bool {
+	return len(r.Failed) > 0 && len(r.Created)+len(r.Updated) == 0
+}
+
+// observeResult records the result of a sync in the metrics.
+func observeResult(r *Result) {
+	metrics.PagesSynced.Add(float64(len(r.Created)))
+	metrics.PagesFailed.Add(float64(len(r.Failed)))
+
+	// Only a full success counts as the last success, so that alerting on
+	// it catches pages that keep failing.
+	switch {
+	case r.Error != "" || r.TotalFailure():
+		metrics.SyncRuns.WithLabelValues("failure").Inc()
+	case r.PartialFailure():
+		metrics.SyncRuns.WithLabelValues("partial").Inc()
+	default:
+		metrics.SyncRuns.WithLabelValues("success").Inc()
+		metrics.LastSuccess.SetToCurrentTime()
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The duration is encoded in
+// seconds.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(&struct {
+		*plain
+		Duration float64 `json:"duration_seconds"`
+	}{(*plain)(r), r.Duration.Seconds()})
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRunning is returned by [Runner.Start] when a sync is already
+// running.
+var ErrRunning = errors.New("a sync is already running")
+
+// ErrStopped is returned by [Runner.Start] once [Runner.Wait] has been
+// called.
+var ErrStopped = errors.New("the runner is stopped")
+
+// Run is a completed sync started by a [Runner].
+type Run struct {
+	// Started is when the sync started.
+	Started time.Time `json:"started"`
+
+	// Result is the result of the sync.
+	Result *Result `json:"result"`
+
+	// Err is the error returned by the sync, if any.
+	Err error `json:"-"`
+}
+
+// Status is the status of a [Runner].
+type Status struct {
+	// Running is true while a sync is running.
+	Running bool `json:"running"`
+
+	// LastRun is the last completed sync. Nil if there wasn't one yet.
+	LastRun *Run `json:"last_run"`
+
+	// Documents is the status of every synced document, as of the last
+	// completed sync.
+	Documents []DocumentStatus `json:"documents"`
+}
+
+// Runner runs syncs one at a time, e.g., when they're triggered both on
+// an interval and through the API. Safe for concurrent use. Create with
+// [NewRunner].
+type Runner struct {
+	s *Syncer
+
+	// OnRun is called with every completed run, if set. Must be set
+	// before the first run.
+	OnRun func(ctx context.Context, run *Run)
+
+	// wg tracks the running sync, see [Runner.Wait].
+	wg sync.WaitGroup
+
+	// mu protects the fields below.
+	mu      sync.Mutex
+	running bool
+	stopped bool
+	last    *Run
+	docs    []DocumentStatus
+}
+
+// NewRunner creates a new [Runner] for the provided syncer. The syncer
+// must not be used directly afterwards.
+func NewRunner(s *Syncer) *Runner {
+	r := &Runner{s: s}
+	r.refreshDocuments()
+	return r
+}
+
+// refreshDocuments updates the status of the documents. The previous
+// status is kept if it can't be read.
+func (r *Runner) refreshDocuments() {
+	docs, err := r.s.documentStatus()
+	if err != nil {
+		r.s.log.With("error", err).Warn("failed to read document status")
+		return
+	}
+
+	r.mu.Lock()
+	r.docs = docs
+	r.mu.Unlock()
+}
+
+// Start starts a sync in the background. The returned channel receives
+// the run once it completes. Returns [ErrRunning] if a sync is already
+// running, or [ErrStopped] once [Runner.Wait] has been called.
+func (r *Runner) Start(ctx context.Context, opts *Options) (<-chan *Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return nil, ErrStopped
+	}
+	if r.running {
+		return nil, ErrRunning
+	}
+	r.running = true
+	r.wg.Add(1)
+
+	done := make(chan *Run, 1)
+	go func() {
+		defer r.wg.Done()
+
+		run := &Run{Started: time.Now()}
+		run.Result, run.Err = r.s.Sync(ctx, opts)
+		r.refreshDocuments()
+
+		r.mu.Lock()
+		r.running, r.last = false, run
+		r.mu.Unlock()
+
+		done <- run
+		if r.OnRun != nil {
+			r.OnRun(ctx, run)
+		}
+	}()
+	return done, nil
+}
+
+// Wait stops new syncs from being started and waits for the running one,
+// if any, to finish. Call before closing the syncer, so that a sync
+// doesn't use the state once it's closed.
+func (r *Runner) Wait() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+
+	r.wg.Wait()
+}
+
+// Status returns the current status.
+func (r *Runner) Status() *Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Status{Running: r.running, LastRun: r.last, Documents: r.docs}
+}
+
+// Preview returns the path of a preview of a page, see [Syncer.Preview].
+func (r *Runner) Preview(id string) (string, error) {
+	return r.s.Preview(id)
+}
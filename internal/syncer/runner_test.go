@@ -0,0 +1,69 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunnerWait(t *testing.T) {
+	s, _ := newTestSyncer(t, blankPages(t, 2))
+	src := s.source.(*fakeSource)
+	src.block = make(chan struct{})
+
+	r := NewRunner(s)
+	done, err := r.Start(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		r.Wait()
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatal("Wait() returned while a sync was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := r.Start(context.Background(), nil); !errors.Is(err, ErrStopped) {
+		t.Errorf("Start() after Wait() error = %v, want %v", err, ErrStopped)
+	}
+
+	close(src.block)
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait() didn't return once the sync finished")
+	}
+
+	// The run completed before Wait returned.
+	select {
+	case run := <-done:
+		if run.Err != nil {
+			t.Errorf("run error = %v", run.Err)
+		}
+	default:
+		t.Error("Wait() returned before the run completed")
+	}
+}
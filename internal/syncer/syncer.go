@@ -20,15 +20,21 @@
 package syncer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/jaredallard/remarkabledayone/internal/config"
 	"github.com/jaredallard/remarkabledayone/internal/dayone"
+	"github.com/jaredallard/remarkabledayone/internal/ocr"
 	"github.com/jaredallard/remarkabledayone/internal/rm"
 	"github.com/jaredallard/remarkabledayone/internal/state"
-	"github.com/juruen/rmapi/model"
 )
 
 // Syncer implements a syncer between remarkable and dayone. Create with
@@ -36,113 +42,437 @@ import (
 type Syncer struct {
 	cfg   *config.Config
 	log   *slog.Logger
-	state *state.State
-	rm    *rm.Client
+	state state.Store
+
+	// source is where documents are read from.
+	source rm.Source
+
+	// ocr is used to recognize handwriting on rendered pages. Nil if OCR
+	// is disabled.
+	ocr ocr.Provider
+
+	// previewDir is where a preview of every rendered page is kept, see
+	// [Syncer.Preview]. Previews aren't kept if empty.
+	previewDir string
 }
 
-// New creates a new syncer.
-func New(log *slog.Logger, cfg *config.Config) (*Syncer, error) {
-	st := state.Load(log.With("component", "state"))
-	if st.SyncedPages == nil {
-		st.SyncedPages = make(map[string]struct{})
+// New creates a new syncer. The state is locked until the syncer is
+// closed with [Syncer.Close], an error wrapping [state.ErrLocked] is
+// returned if another process is using it.
+func New(log *slog.Logger, cfg *config.Config) (_ *Syncer, err error) {
+	if cfg.DocumentName == "" {
+		return nil, fmt.Errorf("DOCUMENT_NAME must be set")
 	}
 
-	//nolint:gocritic // Why: Acceptable shadow.
-	rm, err := rm.New(log.With("component", "remarkable"))
+	st, err := OpenState(log, cfg, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			st.Close() //nolint:errcheck // Why: Best effort.
+		}
+	}()
+	recognizer, err := newRecognizer(cfg, st)
 	if err != nil {
-		log.With("error", err).Error("failed to create remarkable client")
-		os.Exit(1)
+		return nil, err
+	}
+
+	source, err := newSource(log, cfg, st)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewWithSource(log, cfg, st, source, recognizer)
+
+	// Previews are only served by the API.
+	if cfg.APIAddr != "" {
+		dir, err := CacheDir(cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.previewDir = filepath.Join(dir, "previews")
 	}
 
+	return s, nil
+}
+
+// NewReadOnly creates a syncer that only reads the state, e.g., to
+// compare it with a document through [Syncer.Diff]. The state isn't
+// locked, so it can be used while another process is syncing. It can't
+// sync, and OCR is disabled.
+func NewReadOnly(log *slog.Logger, cfg *config.Config) (_ *Syncer, err error) {
+	st, err := OpenState(log, cfg, true)
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing has been synced yet, use an empty state that's never
+		// saved.
+		st, err = state.OpenFile(log, "", true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			st.Close() //nolint:errcheck // Why: Best effort.
+		}
+	}()
+
+	source, err := newSource(log, cfg, st)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithSource(log, cfg, st, source, nil), nil
+}
+
+// NewWithSource creates a new syncer reading documents from the provided
+// source. recognizer may be nil to disable OCR.
+func NewWithSource(log *slog.Logger, cfg *config.Config, st state.Store, source rm.Source,
+	recognizer ocr.Provider) *Syncer {
 	return &Syncer{
-		cfg:   cfg,
-		log:   log.With("component", "syncer"),
-		state: st,
-		rm:    rm,
-	}, nil
+		cfg:    cfg,
+		log:    log.With("component", "syncer"),
+		state:  st,
+		source: source,
+		ocr:    recognizer,
+	}
 }
 
-// Sync syncs the configured document with DayOne.
-func (s *Syncer) Sync() error {
-	s.log.Info("syncing document", "name", s.cfg.DocumentName)
-	var docMeta *model.Document
-	for _, n := range s.rm.ListDocuments() {
-		if n.Name() == s.cfg.DocumentName {
-			docMeta = n.Document
-			break
+// Close closes the state, releasing its lock.
+func (s *Syncer) Close() error {
+	return s.state.Close()
+}
+
+// newSource creates the configured [rm.Source]. Pages that have been
+// synced and haven't changed since, according to the provided state,
+// aren't downloaded from the cloud.
+func newSource(log *slog.Logger, cfg *config.Config, st state.Store) (rm.Source, error) {
+	switch cfg.Source {
+	case "cloud", "":
+		auth, err := rm.NewAuth(cfg.TokenPath, cfg.DeviceToken)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := rm.New(log.With("component", "remarkable"), auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remarkable client: %w", err)
+		}
+
+		cacheDir, err := CacheDir(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c.BlobDir = filepath.Join(cacheDir, "blobs")
+		c.Unchanged = func(pageID, hash string) bool {
+			p, err := st.Page(pageID)
+			return err == nil && p != nil && p.Synced && p.Hash == hash
 		}
+
+		cache, err := rm.NewCache(log.With("component", "cache"), c,
+			filepath.Join(cacheDir, "documents"), cfg.CacheMaxSize*1024*1024)
+		if err != nil {
+			return nil, err
+		}
+		cache.BlobDir = c.BlobDir
+		return cache, nil
+	case "local":
+		l, err := rm.NewLocal(log.With("component", "local"), cfg.LocalDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open local document tree: %w", err)
+		}
+		return l, nil
+	default:
+		return nil, fmt.Errorf("unknown source %q", cfg.Source)
 	}
-	if docMeta == nil {
-		return fmt.Errorf("document not found")
+}
+
+// CacheDir returns the configured cache directory, defaulting to
+// [rm.DefaultCacheDir].
+func CacheDir(cfg *config.Config) (string, error) {
+	if cfg.CacheDir != "" {
+		return cfg.CacheDir, nil
 	}
+	return rm.DefaultCacheDir()
+}
 
-	doc, err := s.rm.DownloadDocument(docMeta)
+// StatePath returns the path of the configured state: STATE_PATH if set,
+// otherwise the default file of the backend in STATE_DIR if set, or found
+// with [state.Locate].
+func StatePath(log *slog.Logger, cfg *config.Config) (string, error) {
+	if cfg.StatePath != "" {
+		return cfg.StatePath, nil
+	}
+
+	name, err := state.DefaultFile(cfg.StateBackend)
 	if err != nil {
-		return fmt.Errorf("failed to download document: %w", err)
+		return "", err
+	}
+	if cfg.StateDir != "" {
+		return filepath.Join(cfg.StateDir, name), nil
 	}
-	defer os.RemoveAll(doc.Path) //nolint:errcheck // Why: Best effort.
+	return state.Locate(log, name), nil
+}
 
-	s.log.Info("fetched document", "path", doc.Path, "pages", len(doc.Zip.Pages))
+// OpenState opens the configured state, see [StatePath] and [state.Open].
+func OpenState(log *slog.Logger, cfg *config.Config, readOnly bool) (state.Store, error) {
+	log = log.With("component", "state")
+	path, err := StatePath(log, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return state.Open(log, cfg.StateBackend, path, readOnly)
+}
 
-	// Compare the pages we have synced with the pages in the document.
-	pagesHM := make(map[string]struct{})
-	needToSync := make([]int, 0)
-	for i, p := range doc.Zip.Pages {
-		// Used for cleanup later.
-		pagesHM[p.ID] = struct{}{}
+// Options controls a single run of [Syncer.Sync].
+type Options struct {
+	// Filter selects which pages of the document are synced. Pages that
+	// don't match are left untouched.
+	Filter *Filter
+
+	// MarkSynced records the selected pages as synced without creating
+	// entries for them. Useful for baselining existing pages.
+	MarkSynced bool
+
+	// NoCache downloads the document even if it's cached.
+	NoCache bool
+
+	// Document is the name or path of the document to sync instead of the
+	// configured one.
+	Document string
+}
+
+// Sync syncs the configured document, or the one selected in the
+// options, with DayOne. Pages that fail to
+// sync don't cause an error, they're reported in the returned [Result].
+// An error is returned if the sync couldn't be completed, in which case
+// the result describes the pages handled until then.
+//
+// Cancelling the context stops the sync, pages that were synced before
+// that are still recorded in the state.
+func (s *Syncer) Sync(ctx context.Context, opts *Options) (res *Result, err error) {
+	if opts == nil {
+		opts = &Options{}
+	}
 
-		if _, ok := s.state.SyncedPages[p.ID]; ok {
+	name := opts.Document
+	if name == "" {
+		name = s.cfg.DocumentName
+	}
+
+	start := time.Now()
+	res = NewResult(name)
+	defer func() {
+		res.Duration = time.Since(start)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		observeResult(res)
+	}()
+
+	if s.cfg.SyncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.SyncTimeout)
+		defer cancel()
+	}
+
+	s.log.Info("syncing document", "name", name)
+	info, doc, err := s.fetchDocument(ctx, name, opts.NoCache)
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		doc.Close() //nolint:errcheck // Why: Best effort.
+	}()
+
+	s.log.Info("fetched document", "path", doc.Path, "pages", len(doc.Zip.Pages))
+
+	// Compare the pages we have synced with the selected pages in the
+	// document.
+	selected := opts.Filter.Apply(doc.Zip.Pages)
+	if len(selected) != len(doc.Zip.Pages) {
+		s.log.Info("filtered pages", "selected", len(selected), "total", len(doc.Zip.Pages))
+	}
+	known, err := s.state.Pages()
+	if err != nil {
+		return res, fmt.Errorf("failed to read state: %w", err)
+	}
+	needToSync := make([]rm.Page, 0)
+	now := time.Now()
+	for _, p := range selected {
+		if sp, ok := known[p.ID]; ok && sp.Synced {
 			s.log.Debug("page already synced", "page", p.ID)
 			continue
 		}
+		if reason := s.holdReason(known[p.ID], now); reason != "" && !opts.MarkSynced {
+			s.log.With("page", p.ID, "index", p.Index+1, "reason", reason).Info("not attempting failing page")
+			res.Skipped = append(res.Skipped, newPageResult(&p, reason))
+			continue
+		}
+
+		needToSync = append(needToSync, p)
+	}
+
+	// Pages that were synced when the document was downloaded, but have
+	// since been forgotten, weren't downloaded.
+	if !opts.MarkSynced && slices.ContainsFunc(needToSync, func(p rm.Page) bool { return p.Path == "" }) {
+		s.log.Info("pages to sync weren't downloaded, downloading the document again")
+		newInfo, newDoc, err := s.fetchDocument(ctx, name, true)
+		if err != nil {
+			return res, err
+		}
+		doc.Close() //nolint:errcheck // Why: Best effort.
+		info, doc = newInfo, newDoc
 
-		needToSync = append(needToSync, i)
+		fetched := make(map[string]rm.Page, len(doc.Zip.Pages))
+		for _, p := range doc.Zip.Pages {
+			fetched[p.ID] = p
+		}
+		for i := range needToSync {
+			p := &needToSync[i]
+			p.Path, p.Hash = fetched[p.ID].Path, fetched[p.ID].Hash
+		}
 	}
 
-	// When we're done, cleanup the state.
+	// When we're done, cleanup the state. Skipped if the sync failed, so
+	// that nothing is removed based on a partial sync.
 	defer func() {
-		// Remove pages that no longer exist from the state.
-		s.log.Info("cleaning up pages in state that no longer exist")
-		for id := range s.state.SyncedPages {
-			if _, ok := pagesHM[id]; !ok {
-				s.log.With("page", id).Info("removing page from state")
-				delete(s.state.SyncedPages, id)
-			}
+		if err != nil {
+			s.log.Info("not cleaning up state after a failed sync")
+			return
 		}
-		if err := s.state.Save(); err != nil {
-			s.log.Warn("failed to save state", "error", err)
+		if err := s.cleanup(info, doc.Zip.Pages); err != nil {
+			s.log.Warn("failed to clean up state", "error", err)
 		}
 	}()
 
 	if len(needToSync) == 0 {
 		s.log.Info("no pages to sync")
-		return nil
+		return res, nil
 	}
 
-	s.log.With("pages", len(needToSync)).Info("syncing pages")
-	for _, p := range needToSync {
-		page := doc.Zip.Pages[p]
-		s.log.With("page", page.ID).Info("syncing page")
+	if opts.MarkSynced {
+		for _, p := range needToSync {
+			s.log.With("page", p.ID, "index", p.Index+1).Info("marking page as synced")
+			if err := s.state.UpdatePage(p.ID, nil, markSynced("")); err != nil {
+				return res, fmt.Errorf("failed to record page as synced: %w", err)
+			}
+			res.Updated = append(res.Updated, newPageResult(&p, ""))
+		}
+		return res, nil
+	}
+
+	s.log.With("pages", len(needToSync), "workers", max(s.cfg.RenderWorkers, 1)).Info("syncing pages")
+
+	// Pages are rendered concurrently, but entries are created in order so
+	// that they're sorted the same way as in the notebook.
+	fail := func(page *rm.Page, err error) {
+		res.Failed = append(res.Failed, newPageResult(page, err.Error()))
+
+		// Interrupted pages didn't fail on their own.
+		if ctx.Err() == nil && s.recordFailure(page.ID, err) {
+			res.Quarantined = append(res.Quarantined, newPageResult(page, err.Error()))
+		}
+	}
+	renderCtx, cancelRender := context.WithCancel(ctx)
+	results, wait := s.renderPages(renderCtx, needToSync)
+	defer wait()
+	defer cancelRender()
+	for i, result := range results {
+		r := <-result
+		if ctx.Err() != nil {
+			break
+		}
+
+		page := r.page
+		log := s.log.With("page", page.ID, "index", page.Index+1, "progress", fmt.Sprintf("%d/%d", i+1, len(needToSync)))
 
-		// Render the page to a PNG.
-		if err := page.Render(); err != nil {
-			s.log.With("error", err).Error("failed to render page")
+		switch {
+		case r.err != nil:
+			log.With("error", r.err).Error("failed to sync page")
+			fail(page, r.err)
+			continue
+		case r.skip != "":
+			log.With("reason", r.skip).Info("skipping page")
+			res.Skipped = append(res.Skipped, newPageResult(page, r.skip))
 			continue
 		}
 
-		if err := dayone.EntryFromPNG(page.PNGPath, "Remarkable Entry", []string{"Remarkable"}); err != nil {
-			s.log.With("error", err).Error("failed to create dayone entry")
+		entryID, err := dayone.EntryFromPNGs(ctx, page.PNGPaths, "Remarkable Entry", r.body, []string{"Remarkable"})
+		if err != nil {
+			log.With("error", err).Error("failed to create dayone entry")
+			fail(page, fmt.Errorf("failed to create dayone entry: %w", err))
 			continue
 		}
 
-		s.state.SyncedPages[page.ID] = struct{}{}
+		// Committed after every entry so that an interrupted sync doesn't
+		// create duplicate entries next time. If that's not possible, stop
+		// before creating more of them.
+		attempt := &state.Attempt{Time: time.Now(), EntryID: entryID}
+		if err := s.state.UpdatePage(page.ID, attempt, markSynced(entryID)); err != nil {
+			res.Created = append(res.Created, newPageResult(page, ""))
+			return res, fmt.Errorf("failed to record page %s as synced, its entry may be duplicated: %w", page.ID, err)
+		}
+		res.Created = append(res.Created, newPageResult(page, ""))
+		log.Info("synced page", "entry", entryID)
+	}
+
+	s.log.With("created", len(res.Created), "skipped", len(res.Skipped), "failed", len(res.Failed)).
+		Info("synced pages")
+	for _, p := range res.Failed {
+		s.log.With("page", p.ID, "index", p.Page, "error", p.Reason).Warn("page failed to sync")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return res, fmt.Errorf("sync interrupted: %w", err)
+	}
+	return res, nil
+}
+
+// markSynced returns a function recording a page as synced with the
+// provided entry, clearing its failures.
+func markSynced(entryID string) func(p *state.Page) {
+	return func(p *state.Page) {
+		p.Synced = true
+		p.SyncedAt = time.Now()
+		p.EntryID = entryID
+		p.Failure = nil
+	}
+}
+
+// fetchDocument fetches the document with the provided name or path from
+// the source. With noCache, the whole document is downloaded again
+// rather than reusing cached files.
+func (s *Syncer) fetchDocument(ctx context.Context, name string, noCache bool) (*rm.DocumentInfo, *rm.Document, error) {
+	src := s.source
+	if noCache {
+		if c, ok := src.(*rm.Cache); ok {
+			src = c.Source
+		}
+		if c, ok := src.(*rm.Client); ok {
+			src = c.Refreshing()
+		}
 	}
 
-	if err := s.state.Save(); err != nil {
-		s.log.Warn("failed to save state", "error", err)
+	info, err := rm.FindDocument(ctx, src, name)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	s.log.With("pages", len(needToSync)).Info("synced pages")
+	doc, err := src.DownloadDocument(ctx, info)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download document: %w", err)
+	}
+	return info, doc, nil
+}
 
-	return nil
+// joinBody joins two sections of an entry body, skipping empty ones.
+func joinBody(a, b string) string {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return a + "\n\n" + b
 }
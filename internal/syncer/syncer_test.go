@@ -0,0 +1,228 @@
+// Copyright (C) 2026 remarkabledayone contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jaredallard/remarkabledayone/internal/config"
+	"github.com/jaredallard/remarkabledayone/internal/rm"
+	"github.com/jaredallard/remarkabledayone/internal/state"
+)
+
+// fakeSource is an in-memory [rm.Source].
+type fakeSource struct {
+	// docs is the documents of the source, their pages are set in the
+	// downloaded [rm.Zip].
+	docs []fakeDocument
+
+	// block, if set, blocks listing documents until it's closed.
+	block chan struct{}
+}
+
+// fakeDocument is a document of a [fakeSource].
+type fakeDocument struct {
+	info  rm.DocumentInfo
+	pages []rm.Page
+}
+
+// ListDocuments implements [rm.Source].
+func (f *fakeSource) ListDocuments(ctx context.Context) ([]rm.DocumentInfo, error) {
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	infos := make([]rm.DocumentInfo, 0, len(f.docs))
+	for _, d := range f.docs {
+		infos = append(infos, d.info)
+	}
+	return infos, nil
+}
+
+// DownloadDocument implements [rm.Source].
+func (f *fakeSource) DownloadDocument(_ context.Context, info *rm.DocumentInfo) (*rm.Document, error) {
+	for _, d := range f.docs {
+		if d.info.ID == info.ID {
+			return &rm.Document{
+				Path: d.info.Path,
+				Zip:  &rm.Zip{ID: d.info.ID, Pages: slices.Clone(d.pages)},
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("document %s not found", info.ID)
+}
+
+// blankPages creates n pages without any content, so that they're
+// skipped rather than rendered when synced.
+func blankPages(t *testing.T, n int) []rm.Page {
+	t.Helper()
+	dir := t.TempDir()
+	header := fmt.Sprintf("%-43s", "reMarkable .lines file, version=6")
+
+	pages := make([]rm.Page, 0, n)
+	for i := range n {
+		p := rm.Page{ID: string(rune('a' + i)), Index: i}
+		p.Path = filepath.Join(dir, p.ID+".rm")
+		if err := os.WriteFile(p.Path, []byte(header), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, p)
+	}
+	return pages
+}
+
+// newTestSyncer creates a [Syncer] reading the provided pages from a
+// [fakeSource], with page "a" already synced.
+func newTestSyncer(t *testing.T, pages []rm.Page) (*Syncer, state.Store) {
+	t.Helper()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	st, err := state.OpenFile(log, filepath.Join(t.TempDir(), state.FileName), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() }) //nolint:errcheck // Why: Best effort.
+	if err := st.UpdatePage("a", nil, markSynced("entry-a")); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &fakeSource{docs: []fakeDocument{
+		{info: rm.DocumentInfo{ID: "other", Name: "Other", Path: "Other"}},
+		{info: rm.DocumentInfo{ID: "journal", Name: "Journal", Path: "Journals/Journal"}, pages: pages},
+	}}
+	cfg := &config.Config{DocumentName: "Journal", SkipBlankPages: true, RenderWorkers: 2}
+	return NewWithSource(log, cfg, st, src, nil), st
+}
+
+// ids returns the IDs of the provided pages, joined.
+func ids(pages []PageResult) string {
+	out := make([]string, 0, len(pages))
+	for _, p := range pages {
+		out = append(out, p.ID)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSync(t *testing.T) {
+	pageRanges, err := ParsePageRanges("1-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		opts        *Options
+		wantUpdated string
+		wantSkipped string
+		wantSynced  []string
+	}{
+		{
+			name:        "new pages",
+			opts:        &Options{},
+			wantSkipped: "b,c,d",
+			wantSynced:  []string{"a"},
+		},
+		{
+			name:        "filtered pages",
+			opts:        &Options{Filter: &Filter{Pages: pageRanges}},
+			wantSkipped: "b,c",
+			wantSynced:  []string{"a"},
+		},
+		{
+			name:        "mark synced",
+			opts:        &Options{Filter: &Filter{Pages: pageRanges}, MarkSynced: true},
+			wantUpdated: "b,c",
+			wantSynced:  []string{"a", "b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, st := newTestSyncer(t, blankPages(t, 4))
+
+			res, err := s.Sync(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Document != "Journal" {
+				t.Errorf("Document = %q, want Journal", res.Document)
+			}
+			if got := ids(res.Created) + ids(res.Failed); got != "" {
+				t.Errorf("created or failed pages %q, want none", got)
+			}
+			if got := ids(res.Updated); got != tt.wantUpdated {
+				t.Errorf("Updated = %q, want %q", got, tt.wantUpdated)
+			}
+			if got := ids(res.Skipped); got != tt.wantSkipped {
+				t.Errorf("Skipped = %q, want %q", got, tt.wantSkipped)
+			}
+
+			pages, err := st.Pages()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var synced []string
+			for id, p := range pages {
+				if p.Synced {
+					synced = append(synced, id)
+				}
+			}
+			slices.Sort(synced)
+			if !slices.Equal(synced, tt.wantSynced) {
+				t.Errorf("synced pages = %v, want %v", synced, tt.wantSynced)
+			}
+			if p := pages["a"]; p == nil || p.EntryID != "entry-a" || p.Document != "journal" {
+				t.Errorf("already synced page changed: %+v", p)
+			}
+
+			docs, err := st.Documents()
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, ok := docs["journal"]
+			if !ok {
+				t.Fatalf("document wasn't recorded: %+v", docs)
+			}
+			if len(d.Pages) != 4 || d.Path != "Journals/Journal" {
+				t.Errorf("recorded document = %+v, want every page", d)
+			}
+		})
+	}
+}
+
+func TestSyncUnknownDocument(t *testing.T) {
+	s, _ := newTestSyncer(t, blankPages(t, 1))
+	res, err := s.Sync(context.Background(), &Options{Document: "Missing"})
+	if err == nil || !strings.Contains(err.Error(), `"Missing" not found`) {
+		t.Fatalf("Sync() error = %v, want the document not to be found", err)
+	}
+	if res.Error == "" {
+		t.Error("result doesn't have the error")
+	}
+}